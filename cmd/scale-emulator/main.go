@@ -0,0 +1,120 @@
+// Package main implements a scale indicator emulator. It answers like a
+// Rhino, Toledo, Torrey or MT-SICS indicator on a Linux pseudo-terminal or
+// a TCP port, so the daemon's serial path can be exercised without
+// hardware:
+//
+//	scale-emulator -marca mt-sics -enlace /tmp/ttyBASCULA
+//	scale-emulator -marca toledo -tcp 127.0.0.1:4001 -escenario retiro.json
+//
+// The daemon is then pointed at the pty path (or tcp://127.0.0.1:4001).
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/adcondev/scale-daemon/internal/scale"
+)
+
+func main() {
+	marca := flag.String("marca", scale.DefaultBrand, "marca del indicador emulado ("+strings.Join(scale.Brands(), ", ")+")")
+	tcpAddr := flag.String("tcp", "", "escuchar en host:puerto en lugar de crear una pseudo-terminal")
+	enlace := flag.String("enlace", "", "enlace simbólico a la pseudo-terminal, p. ej. /tmp/ttyBASCULA")
+	escenario := flag.String("escenario", "", "archivo JSON de escenario (por defecto pesos aleatorios)")
+	semilla := flag.Uint64("semilla", 0, "semilla del escenario (0 usa la del archivo o una aleatoria)")
+	flag.Parse()
+
+	scenario := scale.DefaultScenario()
+	if *escenario != "" {
+		s, err := scale.LoadScenario(*escenario)
+		if err != nil {
+			log.Fatalf("[X] %v", err)
+		}
+		scenario = s
+	}
+	if *semilla != 0 {
+		scenario.Seed = *semilla
+	}
+
+	emu, err := scale.NewEmulator(*marca, scenario)
+	if err != nil {
+		log.Fatalf("[X] %v", err)
+	}
+	log.Printf("[i] Emulando %s - Escenario: %s (semilla %d)", emu.Driver().Name(), scenario.Name, emu.Seed())
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	go emu.Run(ctx)
+
+	if *tcpAddr != "" {
+		err = serveTCP(ctx, emu, *tcpAddr)
+	} else {
+		err = servePTY(ctx, emu, *enlace)
+	}
+	if err != nil {
+		log.Fatalf("[X] %v", err)
+	}
+	log.Println("[i] Emulador detenido")
+}
+
+// serveTCP answers one connection at a time, like a serial-to-Ethernet
+// converter
+func serveTCP(ctx context.Context, emu *scale.Emulator, addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	go func() {
+		<-ctx.Done()
+		_ = ln.Close()
+	}()
+	log.Printf("[OK] Escuchando en tcp://%s", ln.Addr())
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		log.Printf("[>] Conexión de %s", conn.RemoteAddr())
+		if err := serveConn(ctx, emu, conn); err != nil {
+			log.Printf("[!] Conexión cerrada: %v", err)
+		}
+	}
+}
+
+// servePTY creates a pseudo-terminal and answers on its master side
+func servePTY(ctx context.Context, emu *scale.Emulator, enlace string) error {
+	master, name, err := openPTY()
+	if err != nil {
+		return fmt.Errorf("no se pudo crear la pseudo-terminal: %w", err)
+	}
+	log.Printf("[OK] Pseudo-terminal lista: %s", name)
+
+	if enlace != "" {
+		_ = os.Remove(enlace)
+		if err := os.Symlink(name, enlace); err != nil {
+			_ = master.Close()
+			return err
+		}
+		defer func() { _ = os.Remove(enlace) }()
+		log.Printf("[i] Enlace %s -> %s", enlace, name)
+	}
+	return serveConn(ctx, emu, master)
+}
+
+// serveConn serves until the connection fails or ctx is done
+func serveConn(ctx context.Context, emu *scale.Emulator, conn io.ReadWriteCloser) error {
+	defer func() { _ = conn.Close() }()
+	return emu.Serve(ctx, conn)
+}
@@ -0,0 +1,64 @@
+//go:build linux
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// ptyPair is the master side of a pseudo-terminal. The slave side stays
+// open so reads do not fail while the daemon is disconnected.
+type ptyPair struct {
+	*os.File
+	slave *os.File
+}
+
+func (p *ptyPair) Close() error {
+	_ = p.slave.Close()
+	return p.File.Close()
+}
+
+// openPTY creates a pseudo-terminal in raw mode and returns its master
+// side and the slave device path
+func openPTY() (io.ReadWriteCloser, string, error) {
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		return nil, "", err
+	}
+	fd := int(master.Fd())
+	if err := unix.IoctlSetPointerInt(fd, unix.TIOCSPTLCK, 0); err != nil {
+		_ = master.Close()
+		return nil, "", fmt.Errorf("unlockpt: %w", err)
+	}
+	n, err := unix.IoctlGetInt(fd, unix.TIOCGPTN)
+	if err != nil {
+		_ = master.Close()
+		return nil, "", fmt.Errorf("ptsname: %w", err)
+	}
+	name := fmt.Sprintf("/dev/pts/%d", n)
+
+	slave, err := os.OpenFile(name, os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		_ = master.Close()
+		return nil, "", err
+	}
+	// Raw mode so CR and control bytes reach the daemon untouched until it
+	// applies its own line settings
+	tio, err := unix.IoctlGetTermios(int(slave.Fd()), unix.TCGETS)
+	if err == nil {
+		tio.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON
+		tio.Oflag &^= unix.OPOST
+		tio.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+		err = unix.IoctlSetTermios(int(slave.Fd()), unix.TCSETS, tio)
+	}
+	if err != nil {
+		_ = slave.Close()
+		_ = master.Close()
+		return nil, "", fmt.Errorf("modo raw: %w", err)
+	}
+	return &ptyPair{File: master, slave: slave}, name, nil
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package main
+
+import (
+	"errors"
+	"io"
+)
+
+// openPTY is only available on Linux; elsewhere use -tcp
+func openPTY() (io.ReadWriteCloser, string, error) {
+	return nil, "", errors.New("las pseudo-terminales sólo están disponibles en Linux, use -tcp")
+}
@@ -7,9 +7,7 @@ require (
 	github.com/judwhite/go-svc v1.2.1
 	go.bug.st/serial v1.6.4
 	golang.org/x/crypto v0.48.0
+	golang.org/x/sys v0.41.0
 )
 
-require (
-	github.com/creack/goselect v0.1.3 // indirect
-	golang.org/x/sys v0.41.0 // indirect
-)
+require github.com/creack/goselect v0.1.3 // indirect
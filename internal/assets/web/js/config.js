@@ -4,7 +4,8 @@
    ============================================================== */
 const CONFIG = {
     // WebSocket: Weight data (out) + Config (in)
-    WS_URL: `ws://${window.location.hostname}:${window.location.port || 8765}/ws`,
+    // ?estado=1: also receive reader state changes
+    WS_URL: `ws://${window.location.hostname}:${window.location.port || 8765}/ws?estado=1`,
 
     // HTTP: Diagnostics only (no payload data)
     HEALTH_URL: `http://${window.location.hostname}:${window.location.port || 8765}/health`,
@@ -19,7 +20,10 @@ const CONFIG = {
 // Scale brands available for configuration
 const SCALE_BRANDS = [
     { value: 'Rhino BAR 8RS', label: 'Rhino BAR 8RS' },
-    { value: 'rhino', label: 'Rhino (Generic)' }
+    { value: 'rhino', label: 'Rhino (Generic)' },
+    { value: 'Toledo 8142', label: 'Mettler Toledo (Continuo)' },
+    { value: 'MT-SICS', label: 'Mettler Toledo (MT-SICS)' },
+    { value: 'Torrey', label: 'Torrey (L-EQ / EQM / PCR)' }
 ];
 
 // Common COM ports
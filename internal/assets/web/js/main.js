@@ -19,6 +19,14 @@ function setupEventListeners() {
         el.btnApplyConfig.addEventListener('click', sendConfig);
     }
 
+    // Tare / Zero (WebSocket)
+    [['btnTare', 'tare'], ['btnZero', 'zero'], ['btnClearTare', 'clearTare']].forEach(([id, tipo]) => {
+        const btn = document.getElementById(id);
+        if (btn) {
+            btn.addEventListener('click', () => sendOperation(tipo));
+        }
+    });
+
     // HTTP Ping Button
     const btnPing = document.getElementById('btnPing');
     if (btnPing) {
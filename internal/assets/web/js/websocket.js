@@ -1,7 +1,7 @@
 /* ==============================================================
    WEBSOCKET - Scale Daemon
    PROTOCOL CONSTRAINTS (DO NOT MODIFY):
-   - Inbound: 'config' message only
+   - Inbound: 'config', 'units', 'listPorts', 'probe' and 'tare' / 'zero' / 'clearTare' / 'presetTare' messages
    - Outbound: 'ambiente' (once on connect), weight strings (streaming)
    - NO ping/pong/status via WebSocket (use HTTP endpoints)
    ============================================================== */
@@ -11,6 +11,10 @@ const ErrorDescriptions = {
     "ERR_TIMEOUT": "Timeout de lectura.",
     "ERR_READ": "Error de lectura.",
     "ERR_SCALE_CONN": "No se pudo conectar al puerto serial.",
+    "ERR_PARSE": "Respuesta de la báscula no reconocida.",
+    "ERR_OVERLOAD": "Sobrecarga: el peso excede la capacidad de la báscula.",
+    "ERR_UNDERLOAD": "Bajo carga: el peso está por debajo del mínimo de la báscula.",
+    "ERR_PORT_BUSY": "El puerto serial está en uso por otra aplicación.",
 };
 
 function connectWebSocket() {
@@ -63,6 +67,16 @@ function connectWebSocket() {
             handleAmbienteMessage(msg);
         } else if (msg && typeof msg === 'object' && msg.tipo === 'error') {
             handleServerError(msg);   // Handle auth/rate-limit errors
+        } else if (msg && typeof msg === 'object' && msg.tipo === 'resultado') {
+            handleOperationResult(msg);
+        } else if (msg && typeof msg === 'object' && msg.tipo === 'units') {
+            addLog('INFO', `📏 Unidad: ${msg.unidad || 'la del indicador'}`);
+        } else if (msg && typeof msg === 'object' && msg.tipo === 'portList') {
+            handlePortList(msg);
+        } else if (msg && typeof msg === 'object' && msg.tipo === 'probeResult') {
+            handleProbeResult(msg);
+        } else if (msg && typeof msg === 'object' && msg.tipo === 'estado') {
+            handleStateMessage(msg);
         } else {
             handleWeightReading(msg);
         }
@@ -149,6 +163,92 @@ function sendConfig() {
     }
 }
 
+// Send a tare/zero operation; presetTare takes a value and unit
+function sendOperation(tipo, valor, unidad) {
+    const msg = {tipo, auth_token: getAuthToken()};
+    if (tipo === 'presetTare') {
+        msg.valor = valor;
+        if (unidad) {
+            msg.unidad = unidad;
+        }
+    }
+    if (sendMessage(msg)) {
+        addLog('SENT', `📤 Operación: ${tipo}`);
+    }
+}
+
+// Request the host serial ports
+function listPorts() {
+    if (sendMessage({tipo: 'listPorts'})) {
+        addLog('SENT', '📤 Listar puertos');
+    }
+}
+
+// Probe ports for a scale; adoptar applies the first match to this scale
+function probePorts(puertos, adoptar) {
+    const msg = {tipo: 'probe', auth_token: getAuthToken(), adoptar: !!adoptar};
+    if (puertos && puertos.length) {
+        msg.puertos = puertos;
+    }
+    if (sendMessage(msg)) {
+        addLog('SENT', `📤 Sondeo de puertos${adoptar ? ' (adoptar)' : ''}`);
+        showToast('Sondeando puertos...', 'info');
+    }
+}
+
+function handlePortList(msg) {
+    if (!msg.puertos.length) {
+        addLog('INFO', '🔌 No se encontraron puertos seriales');
+        return;
+    }
+    msg.puertos.forEach(p => {
+        const usb = p.usb ? ` USB ${p.vid || '?'}:${p.pid || '?'}${p.serie ? ' ' + p.serie : ''}` : '';
+        const enUso = p.enUso ? ` (en uso: ${p.enUso})` : '';
+        addLog('INFO', `🔌 ${p.nombre}${usb}${enUso}`);
+    });
+}
+
+// Handle reader state changes (connecting, reading, stalled, backoff...)
+function handleStateMessage(msg) {
+    const cause = msg.causa ? ` (${msg.causa})` : '';
+    const from = msg.anterior ? `${msg.anterior} → ` : '';
+    addLog('INFO', `🔌 Báscula: ${from}${msg.estado}${cause}`);
+}
+
+function handleProbeResult(msg) {
+    msg.resultados.forEach(r => {
+        addLog('INFO', `🔍 ${r.puerto}: ${r.marca} ${r.baudios} ${r.bitsDatos}${r.paridad}${r.bitsParada} → ${r.peso}`, 'success');
+    });
+    msg.omitidos.forEach(o => addLog('INFO', `⏭️ ${o.puerto} omitido (${o.motivo})`));
+    if (msg.adoptado) {
+        showToast(`Configuración adoptada: ${msg.adoptado.puerto} ${msg.adoptado.marca}`, 'success');
+    } else {
+        showToast(`Sondeo terminado: ${msg.resultados.length} coincidencia(s)`, msg.resultados.length ? 'success' : 'info');
+    }
+}
+
+const OperationErrors = {
+    'UNSUPPORTED_OPERATION': 'Operación no soportada por la báscula',
+    'NO_READING': 'No hay lectura de peso',
+    'WEIGHT_IN_MOTION': 'Peso en movimiento',
+    'INVALID_TARE': 'Tara inválida',
+    'OPERATION_FAILED': 'La operación falló',
+};
+
+// Handle the reply to a tare/zero operation
+function handleOperationResult(msg) {
+    if (msg.ok) {
+        const origen = msg.origen === 'software' ? ' (software)' : '';
+        const valor = msg.valor !== undefined ? `: ${msg.valor} ${msg.unidad || ''}` : '';
+        addLog('INFO', `✅ ${msg.operacion}${valor}${origen}`, 'success');
+        showToast(`${msg.operacion} aplicada`, 'success');
+        return;
+    }
+    const text = OperationErrors[msg.error] || ErrorDescriptions[msg.error] || `Error: ${msg.error}`;
+    addLog('ERROR', `❌ ${msg.operacion}: ${text}`, 'error');
+    showToast(text, 'error');
+}
+
 // Also handle new error responses from the server
 // In the onmessage handler, add handling for "error" tipo:
 // (Inside handleAmbienteMessage or a new handler)
@@ -156,6 +256,21 @@ function handleServerError(msg) {
     const errorMessages = {
         'AUTH_INVALID_TOKEN': '🔒 Token de autenticación inválido',
         'RATE_LIMITED': '⏳ Demasiados cambios de configuración. Espere un momento.',
+        'UNKNOWN_BRAND': '⚖️ Marca de báscula no soportada',
+        'PORT_IN_USE': '🔌 El puerto ya lo usa otra báscula',
+        'INVALID_SERIAL': '🔌 Parámetros de línea serial inválidos',
+        'INVALID_READ_MODE': '🔁 Modo de lectura inválido',
+        'INVALID_STABILITY': '⚖️ Parámetros de estabilidad inválidos',
+        'INVALID_UNIT': '📏 Unidad no soportada (kg, g, lb, oz)',
+        'UNIT_UNKNOWN': '📏 La báscula no informa su unidad; configure "unidad" para convertir',
+        'INVALID_SCENARIO': '🧪 Escenario de simulación inválido',
+        'INVALID_CAPTURE': '📼 Ruta de captura inválida',
+        'INVALID_RETRY': '🔁 Parámetros de reintento fuera de rango',
+        'INVALID_PUBLISH': '📉 Parámetros de publicación fuera de rango',
+        'INVALID_RANGE': '⚠️ Capacidad o mínimo de la báscula inválidos',
+        'INVALID_TIMEOUT': '⏱️ Tiempo de espera de captura fuera de rango',
+        'PROBE_BUSY': '🔍 Ya hay un sondeo de puertos en curso',
+        'PORT_LIST_FAILED': '🔌 No se pudieron listar los puertos seriales',
     };
     const text = errorMessages[msg.error] || `Error: ${msg.error}`;
     addLog('ERROR', text, 'error');
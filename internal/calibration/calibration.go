@@ -0,0 +1,68 @@
+// Package calibration implements the verification procedure required by
+// weights-and-measures inspections: reference masses are placed on a
+// scale, stable readings are captured and their error is compared with the
+// maximum permissible error of the scale's accuracy class. Finished
+// sessions are stored as signed reports.
+package calibration
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Class is an accuracy class of non-automatic weighing instruments
+// (OIML R 76 / NIST HB 44)
+type Class string
+
+// Accuracy classes
+const (
+	ClassI    Class = "I"    // special accuracy
+	ClassII   Class = "II"   // high accuracy
+	ClassIII  Class = "III"  // medium accuracy, most retail scales
+	ClassIIII Class = "IIII" // ordinary accuracy
+)
+
+// ErrInvalidClass is returned for an unknown accuracy class
+var ErrInvalidClass = errors.New("clase de exactitud inválida")
+
+// mpeBands lists, per class, the load limits in verification divisions (e)
+// up to which the maximum permissible error is 0.5 e and 1 e. Heavier
+// loads allow 1.5 e.
+var mpeBands = map[Class][2]float64{
+	ClassI:    {50000, 200000},
+	ClassII:   {5000, 20000},
+	ClassIII:  {500, 2000},
+	ClassIIII: {50, 200},
+}
+
+// ParseClass validates a class name. Empty means ClassIII.
+func ParseClass(s string) (Class, error) {
+	c := Class(strings.ToUpper(strings.TrimSpace(s)))
+	if c == "" {
+		return ClassIII, nil
+	}
+	if _, ok := mpeBands[c]; !ok {
+		return "", fmt.Errorf("%w: %q", ErrInvalidClass, s)
+	}
+	return c, nil
+}
+
+// MPE returns the maximum permissible error for a load, both in the unit
+// of e. In-service inspections allow twice the error of initial
+// verification.
+func (c Class) MPE(load, e float64, inService bool) float64 {
+	bands := mpeBands[c]
+	divisions := load / e
+	mpe := 1.5 * e
+	switch {
+	case divisions <= bands[0]:
+		mpe = 0.5 * e
+	case divisions <= bands[1]:
+		mpe = e
+	}
+	if inService {
+		mpe *= 2
+	}
+	return mpe
+}
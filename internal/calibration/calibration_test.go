@@ -0,0 +1,209 @@
+package calibration
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adcondev/scale-daemon/internal/config"
+	"github.com/adcondev/scale-daemon/internal/scale"
+)
+
+func TestMPE(t *testing.T) {
+	for _, tt := range []struct {
+		class     Class
+		load, e   float64
+		inService bool
+		want      float64
+	}{
+		{ClassIII, 0, 0.01, false, 0.005},
+		{ClassIII, 5, 0.01, false, 0.005},
+		{ClassIII, 5.01, 0.01, false, 0.01},
+		{ClassIII, 20, 0.01, false, 0.01},
+		{ClassIII, 30, 0.01, false, 0.015},
+		{ClassIII, 30, 0.01, true, 0.03},
+		{ClassII, 30, 0.01, false, 0.005},
+		{ClassIIII, 1, 0.01, false, 0.01},
+	} {
+		if got := tt.class.MPE(tt.load, tt.e, tt.inService); got != tt.want {
+			t.Errorf("%s.MPE(%v, %v, %v) = %v, want %v", tt.class, tt.load, tt.e, tt.inService, got, tt.want)
+		}
+	}
+
+	if c, err := ParseClass(" ii "); err != nil || c != ClassII {
+		t.Errorf("ParseClass(ii) = %q, %v", c, err)
+	}
+	if _, err := ParseClass("V"); !errors.Is(err, ErrInvalidClass) {
+		t.Errorf("ParseClass(V) error = %v", err)
+	}
+}
+
+// simulator starts a test-mode reader playing the scenario steps in a loop
+func simulator(t *testing.T, ctx context.Context, steps string) *scale.Reader {
+	t.Helper()
+	orig := scale.CaptureDir
+	scale.CaptureDir = t.TempDir()
+	t.Cleanup(func() { scale.CaptureDir = orig })
+	path := filepath.Join(scale.CaptureDir, "masa.json")
+	scenario := `{"intervaloMs": 10, "repetir": true, "pasos": [` + steps + `]}`
+	if err := os.WriteFile(path, []byte(scenario), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.New(config.Environment{DefaultPort: "COM_TEST", DefaultMode: true})
+	cfg.UpdateScenario("masa.json")
+	broadcast := make(chan scale.Event, 10)
+	r := scale.NewReader(cfg, broadcast)
+	go r.Start(ctx)
+	go func() {
+		for {
+			select {
+			case <-broadcast:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return r
+}
+
+func TestSessionAgainstSimulator(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	// The inspector lifts and replaces the mass between points
+	reader := simulator(t, ctx, `{"tipo": "peso", "peso": 10.01, "duracionMs": 200},
+		{"tipo": "peso", "ruido": 0.05, "duracionMs": 50}`)
+
+	dir, keyPath := t.TempDir(), filepath.Join(t.TempDir(), KeyFile)
+	m, err := NewManager(dir, keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rep, err := m.Start(Options{Bascula: "principal", Clase: ClassIII})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Start(Options{Bascula: "principal"}); !errors.Is(err, ErrSessionActive) {
+		t.Errorf("Second Start() error = %v, want ErrSessionActive", err)
+	}
+	if _, err := m.Finish(rep.ID); !errors.Is(err, ErrNoPoints) {
+		t.Errorf("Finish() without points error = %v", err)
+	}
+
+	// e = 0.01 kg, 10 kg = 1000 e: MPE is 1 e
+	for _, tt := range []struct {
+		reference float64
+		pass      bool
+	}{
+		{10.00, true},
+		{9.98, false},
+	} {
+		p, err := m.Measure(ctx, rep.ID, reader, tt.reference)
+		if err != nil {
+			t.Fatalf("Measure(%v) error = %v", tt.reference, err)
+		}
+		if p.Lectura != 10.01 || p.EMP != 0.01 || p.Aprobado != tt.pass {
+			t.Errorf("Measure(%v) = %+v", tt.reference, p)
+		}
+	}
+	if _, err := m.Measure(ctx, rep.ID, reader, -1); !errors.Is(err, ErrInvalidReference) {
+		t.Errorf("Measure(-1) error = %v", err)
+	}
+
+	final, err := m.Finish(rep.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if final.Aprobado || final.Unidad != "kg" || final.Division != 0.01 || final.Firma == "" {
+		t.Errorf("Finish() = %+v", final)
+	}
+
+	// A new manager reloads the report and the key from disk
+	m2, err := NewManager(dir, keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, err := m2.Report(rep.ID)
+	if err != nil {
+		t.Fatalf("Report() error = %v", err)
+	}
+	if stored.Firma != final.Firma || len(stored.Puntos) != 2 {
+		t.Errorf("Report() = %+v", stored)
+	}
+	if list, err := m2.List(); err != nil || len(list) != 1 || list[0].ID != rep.ID {
+		t.Errorf("List() = %+v, %v", list, err)
+	}
+
+	var buf bytes.Buffer
+	if err := stored.CSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "10,10.01,0.01,0.01,true,") || !strings.Contains(buf.String(), "firma,"+final.Firma) {
+		t.Errorf("CSV() =\n%s", buf.String())
+	}
+
+	stored.Puntos[1].Aprobado = true
+	if err := stored.Verify(); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("Verify() after tampering = %v", err)
+	}
+	if _, err := m2.Report("../firma"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Report(../firma) error = %v", err)
+	}
+
+	// A modified report re-signed with another key is self-consistent but
+	// not the daemon's
+	_, forger, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stored.sign(forger); err != nil {
+		t.Fatal(err)
+	}
+	if err := stored.Verify(); err != nil {
+		t.Fatalf("Verify() of re-signed report = %v", err)
+	}
+	data, _ := json.Marshal(stored)
+	if err := os.WriteFile(filepath.Join(dir, rep.ID+".json"), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m2.Report(rep.ID); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("Report() of re-signed report error = %v, want ErrBadSignature", err)
+	}
+	if list, err := m2.List(); err != nil || len(list) != 0 {
+		t.Errorf("List() with a forged report = %+v, %v", list, err)
+	}
+}
+
+func TestMeasureRequiresNewLoad(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	reader := simulator(t, ctx, `{"tipo": "peso", "peso": 10.01, "duracionMs": 1000}`)
+
+	m, err := NewManager(t.TempDir(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rep, err := m.Start(Options{Bascula: "principal"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Measure(ctx, rep.ID, reader, 10); err != nil {
+		t.Fatalf("First Measure() error = %v", err)
+	}
+
+	// The first mass never left the platform
+	waitCtx, waitCancel := context.WithTimeout(ctx, 200*time.Millisecond)
+	defer waitCancel()
+	if _, err := m.Measure(waitCtx, rep.ID, reader, 20); !errors.Is(err, scale.ErrNoNewLoad) {
+		t.Errorf("Measure() on the same load error = %v, want ErrNoNewLoad", err)
+	}
+	if got, _ := m.Report(rep.ID); len(got.Puntos) != 1 {
+		t.Errorf("Report() has %d points, want 1", len(got.Puntos))
+	}
+}
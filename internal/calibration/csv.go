@@ -0,0 +1,49 @@
+package calibration
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+)
+
+// CSV writes the report as three blocks separated by blank lines: the
+// session metadata, one row per point and the signature
+func (r Report) CSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	fin := ""
+	if r.Fin != nil {
+		fin = r.Fin.Format(time.RFC3339)
+	}
+	rows := [][]string{
+		{"id", r.ID},
+		{"bascula", r.Bascula},
+		{"marca", r.Marca},
+		{"puerto", r.Puerto},
+		{"clase", string(r.Clase)},
+		{"verificacion", r.Verificacion},
+		{"division", num(r.Division)},
+		{"unidad", r.Unidad},
+		{"operador", r.Operador},
+		{"inicio", r.Inicio.Format(time.RFC3339)},
+		{"fin", fin},
+		{"aprobado", strconv.FormatBool(r.Aprobado)},
+		{},
+		{"referencia", "lectura", "error", "emp", "aprobado", "modo", "timestamp"},
+	}
+	for _, p := range r.Puntos {
+		rows = append(rows, []string{
+			num(p.Referencia), num(p.Lectura), num(p.Error), num(p.EMP),
+			strconv.FormatBool(p.Aprobado), p.Modo, p.Timestamp.Format(time.RFC3339),
+		})
+	}
+	rows = append(rows, []string{}, []string{"clavePublica", r.ClavePublica}, []string{"firma", r.Firma})
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+func num(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,436 @@
+package calibration
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/adcondev/scale-daemon/internal/scale"
+)
+
+// KeyFile is the default name of the signing key. It is created on first
+// use; its public half is embedded in every report. Whoever can read it
+// can forge reports, so keep it out of the reports directory and readable
+// only by the service.
+const KeyFile = "firma.key"
+
+// Verification kinds
+const (
+	// Initial is the verification of a new or repaired scale.
+	Initial = "inicial"
+	// InService is a periodic inspection; it allows twice the error.
+	InService = "servicio"
+)
+
+// Errors returned by the Manager
+var (
+	ErrNotFound         = errors.New("sesión de verificación no encontrada")
+	ErrSessionActive    = errors.New("la báscula ya tiene una verificación en curso")
+	ErrInvalidReference = errors.New("masa de referencia inválida")
+	ErrInvalidOptions   = errors.New("parámetros de verificación inválidos")
+	ErrUnitMismatch     = errors.New("la unidad de la lectura no coincide con la de la sesión")
+	ErrNoPoints         = errors.New("la verificación no tiene mediciones")
+	ErrBadSignature     = errors.New("firma del informe inválida")
+)
+
+// validID keeps report IDs safe to use as file names
+var validID = regexp.MustCompile(`^[0-9]{8}-[0-9]{6}-[0-9a-f]{8}$`)
+
+// Waiter captures the stable reading of a new load; *scale.Reader
+// implements it
+type Waiter interface {
+	WaitNewLoad(ctx context.Context, prev *scale.Reading, division float64) (scale.Reading, error)
+}
+
+// Options start a verification session
+type Options struct {
+	Bascula string
+	Marca   string
+	Puerto  string
+	Clase   Class
+	// Verificacion is Initial or InService; empty means Initial.
+	Verificacion string
+	// Division is the verification interval e. Zero takes the resolution
+	// of the first reading.
+	Division float64
+	Operador string
+}
+
+// Point is one reference mass and the reading captured for it
+type Point struct {
+	Referencia float64   `json:"referencia"`
+	Lectura    float64   `json:"lectura"`
+	Error      float64   `json:"error"`
+	EMP        float64   `json:"emp"` // maximum permissible error
+	Aprobado   bool      `json:"aprobado"`
+	Modo       string    `json:"modo"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+// Report is a verification session. Finished reports carry an Ed25519
+// signature over their JSON encoding without Firma.
+type Report struct {
+	ID           string     `json:"id"`
+	Bascula      string     `json:"bascula"`
+	Marca        string     `json:"marca"`
+	Puerto       string     `json:"puerto"`
+	Clase        Class      `json:"clase"`
+	Verificacion string     `json:"verificacion"`
+	Division     float64    `json:"division"`
+	Unidad       string     `json:"unidad"`
+	Operador     string     `json:"operador,omitempty"`
+	Inicio       time.Time  `json:"inicio"`
+	Fin          *time.Time `json:"fin,omitempty"`
+	Puntos       []Point    `json:"puntos"`
+	Aprobado     bool       `json:"aprobado"`
+	ClavePublica string     `json:"clavePublica,omitempty"`
+	Firma        string     `json:"firma,omitempty"`
+}
+
+// session is an active verification. mu serializes measurements.
+type session struct {
+	mu     sync.Mutex
+	report Report
+	// last is the reading of the previous point, so the next one must come
+	// from a new load
+	last *scale.Reading
+}
+
+// Manager runs verification sessions and stores their reports in a
+// directory
+type Manager struct {
+	dir string
+	key ed25519.PrivateKey
+
+	mu       sync.Mutex
+	sessions map[string]*session
+}
+
+// NewManager stores reports in dir and signs them with the key at keyPath,
+// creating both if needed. An empty keyPath keeps the key in dir.
+func NewManager(dir, keyPath string) (*Manager, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	if keyPath == "" {
+		keyPath = filepath.Join(dir, KeyFile)
+	}
+	key, err := loadKey(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	return &Manager{dir: dir, key: key, sessions: make(map[string]*session)}, nil
+}
+
+// loadKey reads the private key seed at path, generating it the first time
+func loadKey(path string) (ed25519.PrivateKey, error) {
+	seed, err := os.ReadFile(path) //nolint:gosec // path comes from the service, not from clients
+	if errors.Is(err, os.ErrNotExist) {
+		seed = make([]byte, ed25519.SeedSize)
+		if _, err := rand.Read(seed); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(path, seed, 0600); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("clave de firma inválida: %s", path)
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// Start opens a session on a scale. Only one session per scale may be
+// active.
+func (m *Manager) Start(opts Options) (Report, error) {
+	if opts.Clase == "" {
+		opts.Clase = ClassIII
+	}
+	if _, ok := mpeBands[opts.Clase]; !ok {
+		return Report{}, fmt.Errorf("%w: %q", ErrInvalidClass, opts.Clase)
+	}
+	if opts.Verificacion == "" {
+		opts.Verificacion = Initial
+	}
+	if opts.Verificacion != Initial && opts.Verificacion != InService {
+		return Report{}, fmt.Errorf("%w: verificación %q", ErrInvalidOptions, opts.Verificacion)
+	}
+	if opts.Division < 0 || math.IsNaN(opts.Division) || math.IsInf(opts.Division, 0) {
+		return Report{}, fmt.Errorf("%w: división %v", ErrInvalidOptions, opts.Division)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, s := range m.sessions {
+		if s.report.Bascula == opts.Bascula {
+			return Report{}, fmt.Errorf("%w: %s", ErrSessionActive, s.report.ID)
+		}
+	}
+	s := &session{report: Report{
+		ID:           newID(),
+		Bascula:      opts.Bascula,
+		Marca:        opts.Marca,
+		Puerto:       opts.Puerto,
+		Clase:        opts.Clase,
+		Verificacion: opts.Verificacion,
+		Division:     opts.Division,
+		Operador:     opts.Operador,
+		Inicio:       time.Now(),
+		Puntos:       []Point{},
+	}}
+	m.sessions[s.report.ID] = s
+	return s.report, nil
+}
+
+// Measure waits for a stable reading with the reference mass on the
+// platform and records its error against the class tolerance. After the
+// first point the platform must have moved, or the weight changed by more
+// than the division, so a point never takes the previous mass's reading;
+// otherwise it fails with scale.ErrNoNewLoad when ctx ends.
+func (m *Manager) Measure(ctx context.Context, id string, w Waiter, reference float64) (Point, error) {
+	if reference < 0 || math.IsNaN(reference) || math.IsInf(reference, 0) {
+		return Point{}, fmt.Errorf("%w: %v", ErrInvalidReference, reference)
+	}
+	s, err := m.active(id)
+	if err != nil {
+		return Point{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m.mu.Lock()
+	division := s.report.Division
+	m.mu.Unlock()
+	reading, err := w.WaitNewLoad(ctx, s.last, division)
+	if err != nil {
+		return Point{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rep := &s.report
+	if rep.Unidad != "" && reading.Unit != "" && reading.Unit != rep.Unidad {
+		return Point{}, fmt.Errorf("%w: %s, se esperaba %s", ErrUnitMismatch, reading.Unit, rep.Unidad)
+	}
+	if rep.Unidad == "" {
+		rep.Unidad = reading.Unit
+	}
+	if rep.Division == 0 {
+		rep.Division = reading.Division
+		if rep.Division == 0 {
+			rep.Division = math.Pow10(-reading.Decimals)
+		}
+	}
+
+	factor := math.Pow10(reading.Decimals)
+	p := Point{
+		Referencia: reference,
+		Lectura:    reading.Value,
+		Error:      math.Round((reading.Value-reference)*factor) / factor,
+		EMP:        rep.Clase.MPE(reference, rep.Division, rep.Verificacion == InService),
+		Modo:       string(reading.Mode),
+		Timestamp:  reading.Time,
+	}
+	p.Aprobado = math.Abs(p.Error) <= p.EMP+1e-9
+	rep.Puntos = append(rep.Puntos, p)
+	s.last = &reading
+	return p, nil
+}
+
+// Finish closes a session, signs its report and stores it
+func (m *Manager) Finish(id string) (Report, error) {
+	s, err := m.active(id)
+	if err != nil {
+		return Report{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m.mu.Lock()
+	rep := s.report
+	m.mu.Unlock()
+	if len(rep.Puntos) == 0 {
+		return Report{}, ErrNoPoints
+	}
+
+	fin := time.Now()
+	rep.Fin = &fin
+	rep.Aprobado = true
+	for _, p := range rep.Puntos {
+		rep.Aprobado = rep.Aprobado && p.Aprobado
+	}
+	if err := rep.sign(m.key); err != nil {
+		return Report{}, err
+	}
+	data, err := json.MarshalIndent(rep, "", "  ")
+	if err != nil {
+		return Report{}, err
+	}
+	if err := os.WriteFile(m.path(rep.ID), data, 0600); err != nil {
+		return Report{}, err
+	}
+
+	m.mu.Lock()
+	delete(m.sessions, id)
+	m.mu.Unlock()
+	return rep, nil
+}
+
+// Cancel discards an active session
+func (m *Manager) Cancel(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.sessions[id]; !ok {
+		return ErrNotFound
+	}
+	delete(m.sessions, id)
+	return nil
+}
+
+// Report returns an active session, unsigned, or a stored report after
+// checking it was signed with the manager's key
+func (m *Manager) Report(id string) (Report, error) {
+	if !validID.MatchString(id) {
+		return Report{}, ErrNotFound
+	}
+	m.mu.Lock()
+	if s, ok := m.sessions[id]; ok {
+		rep := s.report
+		rep.Puntos = append([]Point(nil), rep.Puntos...)
+		m.mu.Unlock()
+		return rep, nil
+	}
+	m.mu.Unlock()
+
+	data, err := os.ReadFile(m.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return Report{}, ErrNotFound
+	}
+	if err != nil {
+		return Report{}, err
+	}
+	var rep Report
+	if err := json.Unmarshal(data, &rep); err != nil {
+		return Report{}, err
+	}
+	return rep, m.verify(rep)
+}
+
+// verify checks the signature of a stored report and that it was made with
+// the manager's key. A report re-signed with any other key is rejected even
+// though its own signature is valid.
+func (m *Manager) verify(rep Report) error {
+	if err := rep.Verify(); err != nil {
+		return err
+	}
+	own := base64.StdEncoding.EncodeToString(m.key.Public().(ed25519.PublicKey))
+	if rep.ClavePublica != own {
+		return fmt.Errorf("%w: firmado con otra clave", ErrBadSignature)
+	}
+	return nil
+}
+
+// List returns the active sessions and the stored reports, newest first
+func (m *Manager) List() ([]Report, error) {
+	var reports []Report
+	m.mu.Lock()
+	for _, s := range m.sessions {
+		rep := s.report
+		rep.Puntos = append([]Point{}, rep.Puntos...)
+		reports = append(reports, rep)
+	}
+	m.mu.Unlock()
+
+	files, err := filepath.Glob(filepath.Join(m.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		id := filepath.Base(f[:len(f)-len(".json")])
+		if !validID.MatchString(id) {
+			continue
+		}
+		rep, err := m.Report(id)
+		if err != nil {
+			// Tampered or unreadable reports are never listed as genuine
+			log.Printf("[!] Informe de verificación %s descartado: %v", id, err)
+			continue
+		}
+		reports = append(reports, rep)
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].Inicio.After(reports[j].Inicio) })
+	return reports, nil
+}
+
+// active returns the active session id
+func (m *Manager) active(id string) (*session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return s, nil
+}
+
+func (m *Manager) path(id string) string {
+	return filepath.Join(m.dir, id+".json")
+}
+
+// sign sets ClavePublica and Firma
+func (r *Report) sign(key ed25519.PrivateKey) error {
+	r.ClavePublica = base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey))
+	r.Firma = ""
+	payload, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	r.Firma = base64.StdEncoding.EncodeToString(ed25519.Sign(key, payload))
+	return nil
+}
+
+// Verify checks Firma against ClavePublica, which only proves the report
+// is intact. Whether ClavePublica is the daemon's key must be checked
+// separately: Manager.Report does so, third parties compare it with the
+// key published by the service.
+func (r Report) Verify() error {
+	pub, err := base64.StdEncoding.DecodeString(r.ClavePublica)
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return ErrBadSignature
+	}
+	sig, err := base64.StdEncoding.DecodeString(r.Firma)
+	if err != nil {
+		return ErrBadSignature
+	}
+	r.Firma = ""
+	payload, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(pub, payload, sig) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// newID returns a unique, time-sortable report ID
+func newID() string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return time.Now().Format("20060102-150405") + "-" + hex.EncodeToString(b)
+}
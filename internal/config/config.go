@@ -4,7 +4,11 @@ package config
 import (
 	"fmt"
 	"log"
+	"math"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 )
 
 // Build variables (injected via ldflags)
@@ -66,24 +70,326 @@ func GetEnvironment(env string) Environment {
 	return Environments["local"]
 }
 
+// Default serial line parameters (Rhino BAR 8RS factory settings)
+const (
+	DefaultBaudRate    = 9600
+	DefaultDataBits    = 8
+	DefaultParity      = "N"
+	DefaultStopBits    = 1.0
+	DefaultReadTimeout = 5 * time.Second
+)
+
+// validBaudRates lists the rates supported by common scale indicators
+var validBaudRates = map[int]bool{
+	300: true, 600: true, 1200: true, 2400: true, 4800: true, 9600: true,
+	14400: true, 19200: true, 38400: true, 57600: true, 115200: true,
+}
+
+// SerialSettings holds the serial line parameters used to open the scale port
+type SerialSettings struct {
+	BaudRate    int
+	DataBits    int
+	Parity      string  // N, E, O, M or S
+	StopBits    float64 // 1, 1.5 or 2
+	ReadTimeout time.Duration
+}
+
+// DefaultSerialSettings returns 9600 8N1 with a 5 s read timeout
+func DefaultSerialSettings() SerialSettings {
+	return SerialSettings{
+		BaudRate:    DefaultBaudRate,
+		DataBits:    DefaultDataBits,
+		Parity:      DefaultParity,
+		StopBits:    DefaultStopBits,
+		ReadTimeout: DefaultReadTimeout,
+	}
+}
+
+// Merge returns s with zero-valued fields filled from base
+func (s SerialSettings) Merge(base SerialSettings) SerialSettings {
+	if s.BaudRate == 0 {
+		s.BaudRate = base.BaudRate
+	}
+	if s.DataBits == 0 {
+		s.DataBits = base.DataBits
+	}
+	if s.Parity == "" {
+		s.Parity = base.Parity
+	}
+	if s.StopBits == 0 {
+		s.StopBits = base.StopBits
+	}
+	if s.ReadTimeout == 0 {
+		s.ReadTimeout = base.ReadTimeout
+	}
+	s.Parity = strings.ToUpper(s.Parity)
+	return s
+}
+
+// Validate checks that every parameter is one the serial driver accepts
+func (s SerialSettings) Validate() error {
+	if !validBaudRates[s.BaudRate] {
+		return fmt.Errorf("baud rate no soportado: %d", s.BaudRate)
+	}
+	if s.DataBits < 5 || s.DataBits > 8 {
+		return fmt.Errorf("bits de datos inválidos: %d", s.DataBits)
+	}
+	switch strings.ToUpper(s.Parity) {
+	case "N", "E", "O", "M", "S":
+	default:
+		return fmt.Errorf("paridad inválida: %q", s.Parity)
+	}
+	switch s.StopBits {
+	case 1, 1.5, 2:
+	default:
+		return fmt.Errorf("bits de parada inválidos: %v", s.StopBits)
+	}
+	if s.ReadTimeout < 100*time.Millisecond || s.ReadTimeout > time.Minute {
+		return fmt.Errorf("timeout de lectura fuera de rango: %s", s.ReadTimeout)
+	}
+	return nil
+}
+
+// String formats the settings in the usual "9600 8N1" notation
+func (s SerialSettings) String() string {
+	return fmt.Sprintf("%d %d%s%s", s.BaudRate, s.DataBits, s.Parity,
+		strconv.FormatFloat(s.StopBits, 'f', -1, 64))
+}
+
+// ReadMode selects how weights are obtained from the indicator
+type ReadMode string
+
+// Read modes
+const (
+	// ReadModeAuto uses the driver's natural mode: continuous for
+	// indicators without a poll command, poll otherwise.
+	ReadModeAuto ReadMode = "auto"
+	// ReadModePoll requests every weight with the driver's poll command.
+	ReadModePoll ReadMode = "poll"
+	// ReadModeContinuous consumes frames the indicator streams unprompted.
+	ReadModeContinuous ReadMode = "continuous"
+)
+
+// ParseReadMode validates a read mode name. Empty means ReadModeAuto.
+func ParseReadMode(s string) (ReadMode, error) {
+	switch m := ReadMode(strings.ToLower(strings.TrimSpace(s))); m {
+	case "":
+		return ReadModeAuto, nil
+	case ReadModeAuto, ReadModePoll, ReadModeContinuous:
+		return m, nil
+	}
+	return "", fmt.Errorf("modo de lectura inválido: %q", s)
+}
+
+// Default stability detection settings
+const (
+	DefaultStabilityWindow    = 5
+	DefaultStabilityTolerance = 1.0
+)
+
+// StabilitySettings controls motion detection for indicators that do not
+// report stability themselves, and whether unstable readings are published
+type StabilitySettings struct {
+	// Window is the number of consecutive readings compared (2-50).
+	Window int
+	// Tolerance is the maximum spread within the window, in divisions.
+	Tolerance float64
+	// StableOnly suppresses readings while the platform is in motion.
+	StableOnly bool
+}
+
+// DefaultStabilitySettings returns a 5-reading window with 1 division tolerance
+func DefaultStabilitySettings() StabilitySettings {
+	return StabilitySettings{
+		Window:    DefaultStabilityWindow,
+		Tolerance: DefaultStabilityTolerance,
+	}
+}
+
+// Validate checks the window and tolerance bounds
+func (s StabilitySettings) Validate() error {
+	if s.Window < 2 || s.Window > 50 {
+		return fmt.Errorf("ventana de estabilidad fuera de rango: %d", s.Window)
+	}
+	if !(s.Tolerance >= 0 && s.Tolerance <= 100) {
+		return fmt.Errorf("tolerancia de estabilidad fuera de rango: %v", s.Tolerance)
+	}
+	return nil
+}
+
+// Default reconnect backoff settings
+const (
+	DefaultRetryInitial    = 3 * time.Second
+	DefaultRetryMax        = time.Minute
+	DefaultRetryMultiplier = 2.0
+	DefaultRetryJitter     = 0.2
+)
+
+// RetrySettings controls the exponential backoff between attempts to
+// reconnect to the scale
+type RetrySettings struct {
+	// Initial is the delay after the first failed attempt.
+	Initial time.Duration
+	// Max caps the delay.
+	Max time.Duration
+	// Multiplier grows the delay after each consecutive failure (1-10).
+	Multiplier float64
+	// Jitter varies each delay randomly by up to ±Jitter of its value (0-1)
+	// so scales on one host do not retry in lockstep.
+	Jitter float64
+}
+
+// DefaultRetrySettings returns 3 s doubling up to 1 min with ±20% jitter
+func DefaultRetrySettings() RetrySettings {
+	return RetrySettings{
+		Initial:    DefaultRetryInitial,
+		Max:        DefaultRetryMax,
+		Multiplier: DefaultRetryMultiplier,
+		Jitter:     DefaultRetryJitter,
+	}
+}
+
+// Validate checks the delays, multiplier and jitter bounds
+func (s RetrySettings) Validate() error {
+	if s.Initial < 100*time.Millisecond || s.Initial > 10*time.Minute {
+		return fmt.Errorf("reintento inicial fuera de rango: %s", s.Initial)
+	}
+	if s.Max < s.Initial || s.Max > time.Hour {
+		return fmt.Errorf("reintento máximo fuera de rango: %s", s.Max)
+	}
+	if s.Multiplier < 1 || s.Multiplier > 10 {
+		return fmt.Errorf("factor de reintento fuera de rango: %v", s.Multiplier)
+	}
+	if s.Jitter < 0 || s.Jitter > 1 {
+		return fmt.Errorf("jitter de reintento fuera de rango: %v", s.Jitter)
+	}
+	return nil
+}
+
+// Delay returns the delay before the given attempt (1 for the first retry)
+// without jitter
+func (s RetrySettings) Delay(attempt int) time.Duration {
+	delay := float64(s.Initial)
+	for i := 1; i < attempt && delay < float64(s.Max); i++ {
+		delay *= s.Multiplier
+	}
+	return min(time.Duration(delay), s.Max)
+}
+
+// Default publishing policy: every reading is sent, as in v1. Change-only
+// publishing is enabled per scale by setting a heartbeat.
+const (
+	DefaultPublishDeadband                = 0.0
+	DefaultPublishHeartbeat time.Duration = 0
+)
+
+// PublishSettings controls which readings are sent to clients. With a
+// heartbeat, readings that did not change are withheld and the last one is
+// re-sent as a heartbeat so clients can still tell the scale is alive.
+type PublishSettings struct {
+	// Deadband is how far, in divisions, a reading must move from the last
+	// published one to be sent (0-100). Zero sends any change.
+	Deadband float64
+	// Heartbeat re-sends the last reading when nothing changed for this
+	// long (500 ms-5 min). Zero, the default, sends every reading, as in
+	// v1.
+	Heartbeat time.Duration
+}
+
+// DefaultPublishSettings returns the v1 policy that publishes every reading
+func DefaultPublishSettings() PublishSettings {
+	return PublishSettings{
+		Deadband:  DefaultPublishDeadband,
+		Heartbeat: DefaultPublishHeartbeat,
+	}
+}
+
+// Validate checks the deadband and heartbeat bounds
+func (s PublishSettings) Validate() error {
+	if s.Deadband < 0 || s.Deadband > 100 {
+		return fmt.Errorf("banda muerta fuera de rango: %v", s.Deadband)
+	}
+	if s.Heartbeat != 0 && (s.Heartbeat < 500*time.Millisecond || s.Heartbeat > 5*time.Minute) {
+		return fmt.Errorf("latido fuera de rango: %s", s.Heartbeat)
+	}
+	return nil
+}
+
+// RangeSettings is the weighing range of a scale, in the indicator's unit.
+// Readings outside it are reported as ERR_OVERLOAD or ERR_UNDERLOAD instead
+// of being published.
+type RangeSettings struct {
+	// Capacity is the maximum gross weight (Max). Zero disables the check.
+	Capacity float64
+	// Minimum is the lowest gross weight accepted, zero or negative (e.g.
+	// -0.05 allows five 10 g divisions below zero). Zero disables the
+	// check; weights below zero are then published as negative.
+	Minimum float64
+}
+
+// Validate checks that the capacity is not negative and the minimum not
+// positive
+func (s RangeSettings) Validate() error {
+	if !(s.Capacity >= 0) || math.IsInf(s.Capacity, 0) {
+		return fmt.Errorf("capacidad inválida: %v", s.Capacity)
+	}
+	if !(s.Minimum <= 0) || math.IsInf(s.Minimum, 0) {
+		return fmt.Errorf("mínimo inválido: %v", s.Minimum)
+	}
+	return nil
+}
+
+// DefaultScaleID identifies the scale served to v1 clients on /ws when no
+// scales file is installed
+const DefaultScaleID = "default"
+
 // Config holds the runtime configuration for the scale service
 type Config struct {
-	mu         sync.RWMutex
-	Puerto     string
-	Marca      string
-	ModoPrueba bool
-	Ambiente   string
-	Dir        string
+	mu          sync.RWMutex
+	ID          string // immutable scale identifier
+	Puerto      string
+	Marca       string
+	ModoPrueba  bool
+	Ambiente    string
+	Dir         string
+	Serial      SerialSettings
+	ModoLectura ReadMode
+	Estabilidad StabilitySettings
+	// Division is the scale interval (d) in the indicator's unit. Zero
+	// derives it from the decimals of each reading.
+	Division float64
+	// Unidad is the unit of readings whose frame carries none, such as the
+	// Rhino's. Empty leaves them without a unit, so they cannot be
+	// converted.
+	Unidad string
+	// Escenario is the simulation scenario file name, in the captures
+	// directory, used in test mode. Empty plays the built-in random scenario.
+	Escenario string
+	// Captura is the file name, in the captures directory, recording the raw
+	// serial traffic. Empty records nothing.
+	Captura string
+	// Reintento is the reconnect backoff.
+	Reintento RetrySettings
+	// Publicacion is the change-only publishing policy.
+	Publicacion PublishSettings
+	// Rango is the weighing range; the zero value disables range checks.
+	Rango RangeSettings
 }
 
 // New creates a Config initialized from the environment
 func New(env Environment) *Config {
 	return &Config{
-		Puerto:     env.DefaultPort,
-		Marca:      "Rhino BAR 8RS",
-		ModoPrueba: env.DefaultMode,
-		Ambiente:   env.Name,
-		Dir:        fmt.Sprintf("ws://%s", env.ListenAddr),
+		ID:          DefaultScaleID,
+		Puerto:      env.DefaultPort,
+		Marca:       "Rhino BAR 8RS",
+		ModoPrueba:  env.DefaultMode,
+		Ambiente:    env.Name,
+		Dir:         fmt.Sprintf("ws://%s", env.ListenAddr),
+		Serial:      DefaultSerialSettings(),
+		ModoLectura: ReadModeAuto,
+		Estabilidad: DefaultStabilitySettings(),
+		Reintento:   DefaultRetrySettings(),
+		Publicacion: DefaultPublishSettings(),
 	}
 }
 
@@ -92,21 +398,43 @@ func (c *Config) Get() Snapshot {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 	return Snapshot{
-		Puerto:     c.Puerto,
-		Marca:      c.Marca,
-		ModoPrueba: c.ModoPrueba,
-		Ambiente:   c.Ambiente,
-		Dir:        c.Dir,
+		ID:          c.ID,
+		Puerto:      c.Puerto,
+		Marca:       c.Marca,
+		ModoPrueba:  c.ModoPrueba,
+		Ambiente:    c.Ambiente,
+		Dir:         c.Dir,
+		Serial:      c.Serial,
+		ModoLectura: c.ModoLectura,
+		Estabilidad: c.Estabilidad,
+		Division:    c.Division,
+		Unidad:      c.Unidad,
+		Escenario:   c.Escenario,
+		Captura:     c.Captura,
+		Reintento:   c.Reintento,
+		Publicacion: c.Publicacion,
+		Rango:       c.Rango,
 	}
 }
 
 // Snapshot is an immutable copy of configuration
 type Snapshot struct {
-	Puerto     string
-	Marca      string
-	ModoPrueba bool
-	Ambiente   string
-	Dir        string
+	ID          string
+	Puerto      string
+	Marca       string
+	ModoPrueba  bool
+	Ambiente    string
+	Dir         string
+	Serial      SerialSettings
+	ModoLectura ReadMode
+	Estabilidad StabilitySettings
+	Division    float64
+	Unidad      string
+	Escenario   string
+	Captura     string
+	Reintento   RetrySettings
+	Publicacion PublishSettings
+	Rango       RangeSettings
 }
 
 // Update applies new configuration values
@@ -133,3 +461,125 @@ func (c *Config) Update(puerto, marca string, modoPrueba bool) bool {
 
 	return changed
 }
+
+// UpdateSerial applies new serial line parameters. Zero-valued fields keep
+// their current value. The merged settings are validated before anything
+// is changed. Returns true if any value changed.
+func (c *Config) UpdateSerial(serial SerialSettings) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	merged := serial.Merge(c.Serial)
+	if err := merged.Validate(); err != nil {
+		return false, err
+	}
+
+	changed := merged != c.Serial
+	c.Serial = merged
+	return changed, nil
+}
+
+// UpdateReadMode sets the read mode. Returns true if it changed.
+func (c *Config) UpdateReadMode(mode ReadMode) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	changed := c.ModoLectura != mode
+	c.ModoLectura = mode
+	return changed
+}
+
+// UpdateUnit sets the unit of readings without one. Returns true if it
+// changed.
+func (c *Config) UpdateUnit(unidad string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	changed := c.Unidad != unidad
+	c.Unidad = unidad
+	return changed
+}
+
+// UpdateScenario sets the test mode scenario file. Returns true if it changed.
+func (c *Config) UpdateScenario(escenario string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	changed := c.Escenario != escenario
+	c.Escenario = escenario
+	return changed
+}
+
+// UpdateCapture sets the serial capture file. Returns true if it changed.
+func (c *Config) UpdateCapture(captura string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	changed := c.Captura != captura
+	c.Captura = captura
+	return changed
+}
+
+// UpdateRetry sets the reconnect backoff after validating it. Returns true
+// if it changed.
+func (c *Config) UpdateRetry(retry RetrySettings) (bool, error) {
+	if err := retry.Validate(); err != nil {
+		return false, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	changed := c.Reintento != retry
+	c.Reintento = retry
+	return changed, nil
+}
+
+// UpdatePublish sets the publishing policy after validating it. Returns
+// true if it changed.
+func (c *Config) UpdatePublish(publish PublishSettings) (bool, error) {
+	if err := publish.Validate(); err != nil {
+		return false, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	changed := c.Publicacion != publish
+	c.Publicacion = publish
+	return changed, nil
+}
+
+// UpdateRange sets the weighing range after validating it. Returns true if
+// it changed.
+func (c *Config) UpdateRange(rng RangeSettings) (bool, error) {
+	if err := rng.Validate(); err != nil {
+		return false, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	changed := c.Rango != rng
+	c.Rango = rng
+	return changed, nil
+}
+
+// UpdateStability sets the stability settings and scale division after
+// validating them. Returns true if anything changed.
+func (c *Config) UpdateStability(stability StabilitySettings, division float64) (bool, error) {
+	if err := stability.Validate(); err != nil {
+		return false, err
+	}
+	if !(division >= 0) || math.IsInf(division, 0) {
+		return false, fmt.Errorf("división inválida: %v", division)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	changed := c.Estabilidad != stability || c.Division != division
+	c.Estabilidad = stability
+	c.Division = division
+	return changed, nil
+}
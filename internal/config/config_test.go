@@ -0,0 +1,193 @@
+package config
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSerialSettingsValidate(t *testing.T) {
+	if err := DefaultSerialSettings().Validate(); err != nil {
+		t.Errorf("Default settings should be valid: %v", err)
+	}
+
+	valid := []SerialSettings{
+		{BaudRate: 4800, DataBits: 7, Parity: "E", StopBits: 1, ReadTimeout: time.Second},
+		{BaudRate: 19200, DataBits: 8, Parity: "n", StopBits: 2, ReadTimeout: 500 * time.Millisecond},
+	}
+	for _, s := range valid {
+		if err := s.Validate(); err != nil {
+			t.Errorf("Validate(%+v) returned error: %v", s, err)
+		}
+	}
+
+	invalid := []SerialSettings{
+		{BaudRate: 9601, DataBits: 8, Parity: "N", StopBits: 1, ReadTimeout: time.Second},
+		{BaudRate: 9600, DataBits: 9, Parity: "N", StopBits: 1, ReadTimeout: time.Second},
+		{BaudRate: 9600, DataBits: 8, Parity: "X", StopBits: 1, ReadTimeout: time.Second},
+		{BaudRate: 9600, DataBits: 8, Parity: "N", StopBits: 3, ReadTimeout: time.Second},
+		{BaudRate: 9600, DataBits: 8, Parity: "N", StopBits: 1, ReadTimeout: time.Millisecond},
+	}
+	for _, s := range invalid {
+		if err := s.Validate(); err == nil {
+			t.Errorf("Validate(%+v) should fail", s)
+		}
+	}
+}
+
+func TestUpdateSerial(t *testing.T) {
+	cfg := New(Environment{DefaultPort: "COM3"})
+
+	changed, err := cfg.UpdateSerial(SerialSettings{BaudRate: 4800, DataBits: 7, Parity: "e"})
+	if err != nil || !changed {
+		t.Fatalf("UpdateSerial = %v, %v; want true, nil", changed, err)
+	}
+	got := cfg.Get().Serial
+	if got.String() != "4800 7E1" || got.ReadTimeout != DefaultReadTimeout {
+		t.Errorf("Merged settings = %+v (%s)", got, got)
+	}
+
+	// Empty update keeps everything
+	if changed, err := cfg.UpdateSerial(SerialSettings{}); err != nil || changed {
+		t.Errorf("Empty UpdateSerial = %v, %v; want false, nil", changed, err)
+	}
+
+	// Invalid update is rejected without side effects
+	if _, err := cfg.UpdateSerial(SerialSettings{BaudRate: 1}); err == nil {
+		t.Error("Expected error for invalid baud rate")
+	}
+	if cfg.Get().Serial != got {
+		t.Error("Invalid update modified the settings")
+	}
+}
+
+func TestRetrySettingsDelay(t *testing.T) {
+	s := DefaultRetrySettings()
+	if err := s.Validate(); err != nil {
+		t.Errorf("Default retry settings should be valid: %v", err)
+	}
+	for attempt, want := range map[int]time.Duration{
+		1: 3 * time.Second, 2: 6 * time.Second, 3: 12 * time.Second, 5: 48 * time.Second, 6: time.Minute, 100: time.Minute,
+	} {
+		if got := s.Delay(attempt); got != want {
+			t.Errorf("Delay(%d) = %s, want %s", attempt, got, want)
+		}
+	}
+
+	invalid := []RetrySettings{
+		{Initial: time.Millisecond, Max: time.Minute, Multiplier: 2},
+		{Initial: time.Minute, Max: time.Second, Multiplier: 2},
+		{Initial: time.Second, Max: time.Minute, Multiplier: 0.5},
+		{Initial: time.Second, Max: time.Minute, Multiplier: 2, Jitter: 1.5},
+	}
+	for _, s := range invalid {
+		if err := s.Validate(); err == nil {
+			t.Errorf("Validate(%+v) should fail", s)
+		}
+	}
+}
+
+func TestUpdateStability(t *testing.T) {
+	cfg := New(Environment{DefaultPort: "COM3"})
+	stability := cfg.Get().Estabilidad
+	if changed, err := cfg.UpdateStability(stability, 0.01); err != nil || !changed {
+		t.Fatalf("UpdateStability(0.01) = %v, %v", changed, err)
+	}
+
+	for _, division := range []float64{-0.01, math.NaN(), math.Inf(1), math.Inf(-1)} {
+		if _, err := cfg.UpdateStability(stability, division); err == nil {
+			t.Errorf("UpdateStability(division %v) should fail", division)
+		}
+	}
+	for _, tolerance := range []float64{-1, 101, math.NaN()} {
+		s := stability
+		s.Tolerance = tolerance
+		if _, err := cfg.UpdateStability(s, 0.01); err == nil {
+			t.Errorf("UpdateStability(tolerance %v) should fail", tolerance)
+		}
+	}
+	if got := cfg.Get(); got.Division != 0.01 || got.Estabilidad != stability {
+		t.Errorf("Invalid update modified the settings: %+v", got)
+	}
+}
+
+func TestLoadScales(t *testing.T) {
+	dir := t.TempDir()
+	env := Environment{Name: "LOCAL", DefaultPort: "COM3"}
+
+	scales, err := LoadScales(filepath.Join(dir, ScalesFile), env)
+	if err != nil || scales != nil {
+		t.Fatalf("Missing file = %v, %v; want nil, nil", scales, err)
+	}
+
+	path := filepath.Join(dir, ScalesFile)
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(`[
+		{"id": "mostrador", "puerto": "COM3", "marca": "Rhino BAR 8RS"},
+		{"id": "piso", "puerto": "COM4", "marca": "Toledo 8142", "baudios": 4800, "bitsDatos": 7, "paridad": "E", "soloEstable": true, "reintentoMaxMs": 300000, "reintentoJitter": 0, "latidoMs": 2000, "capacidad": 150, "minimo": -0.5}
+	]`)
+	scales, err = LoadScales(path, env)
+	if err != nil || len(scales) != 2 {
+		t.Fatalf("LoadScales() = %v, %v", scales, err)
+	}
+	piso := scales[1].Get()
+	if piso.ID != "piso" || piso.Puerto != "COM4" || piso.Serial.String() != "4800 7E1" || !piso.Estabilidad.StableOnly {
+		t.Errorf("Second scale = %+v", piso)
+	}
+	if piso.Reintento.Max != 5*time.Minute || piso.Reintento.Jitter != 0 || piso.Reintento.Initial != DefaultRetryInitial {
+		t.Errorf("Second scale retry = %+v", piso.Reintento)
+	}
+	// Change-only publishing is opt-in; without latidoMs every reading is sent
+	if piso.Publicacion.Heartbeat != 2*time.Second || scales[0].Get().Publicacion.Heartbeat != 0 {
+		t.Errorf("Publish settings = %+v, %+v", piso.Publicacion, scales[0].Get().Publicacion)
+	}
+	if piso.Rango != (RangeSettings{Capacity: 150, Minimum: -0.5}) || scales[0].Get().Rango != (RangeSettings{}) {
+		t.Errorf("Range settings = %+v, %+v", piso.Rango, scales[0].Get().Rango)
+	}
+	if scales[0].Get().Serial != DefaultSerialSettings() {
+		t.Errorf("First scale should keep default line settings, got %s", scales[0].Get().Serial)
+	}
+
+	invalid := []string{
+		`[]`,
+		`[{"id": "Bad ID"}]`,
+		`[{"id": "a", "puerto": "COM3"}, {"id": "a", "puerto": "COM4"}]`,
+		`[{"id": "a", "puerto": "COM3"}, {"id": "b", "puerto": "com3"}]`,
+		`[{"id": "a", "baudios": 1234}]`,
+		`[{"id": "a", "modoLectura": "push"}]`,
+		`[{"id": "a", "reintentoFactor": 20}]`,
+		`[{"id": "a", "latidoMs": 100}]`,
+		`[{"id": "a", "bandaMuerta": -1}]`,
+		`[{"id": "a", "capacidad": -30}]`,
+		`[{"id": "a", "minimo": 0.5}]`,
+	}
+	for _, content := range invalid {
+		write(content)
+		if _, err := LoadScales(path, env); err == nil {
+			t.Errorf("LoadScales(%s) should fail", content)
+		}
+	}
+}
+
+func TestCheckPorts(t *testing.T) {
+	scales := []Snapshot{
+		{ID: "mostrador", Puerto: "COM3"},
+		{ID: "piso", Puerto: "COM4"},
+		{ID: "prueba", Puerto: "COM3", ModoPrueba: true},
+	}
+	if err := CheckPorts(scales); err != nil {
+		t.Errorf("CheckPorts() = %v; test mode does not open the port", err)
+	}
+	scales[1].Puerto = "com3"
+	if err := CheckPorts(scales); err == nil {
+		t.Error("CheckPorts() should fail for COM3 and com3")
+	}
+}
@@ -0,0 +1,200 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ScalesFile is the optional file, next to the log file, listing the
+// scales served by one daemon. Without it a single default scale is served.
+const ScalesFile = "scales.json"
+
+// validScaleID keeps IDs usable in /ws/<id> and ?scale=<id>
+var validScaleID = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// ScaleDefinition is one entry of the scales file. Fields use the same
+// names as the WebSocket config message; omitted fields take the defaults.
+type ScaleDefinition struct {
+	ID         string `json:"id"`
+	Puerto     string `json:"puerto"`
+	Marca      string `json:"marca"`
+	ModoPrueba bool   `json:"modoPrueba"`
+
+	Baudios          int     `json:"baudios"`
+	BitsDatos        int     `json:"bitsDatos"`
+	Paridad          string  `json:"paridad"`
+	BitsParada       float64 `json:"bitsParada"`
+	TimeoutLecturaMs int     `json:"timeoutLecturaMs"`
+	ModoLectura      string  `json:"modoLectura"`
+
+	VentanaEstabilidad    int      `json:"ventanaEstabilidad"`
+	ToleranciaEstabilidad *float64 `json:"toleranciaEstabilidad"`
+	SoloEstable           bool     `json:"soloEstable"`
+	Division              float64  `json:"division"`
+	Unidad                string   `json:"unidad"`
+
+	Escenario string `json:"escenario"`
+	Captura   string `json:"captura"`
+
+	ReintentoInicialMs int      `json:"reintentoInicialMs"`
+	ReintentoMaxMs     int      `json:"reintentoMaxMs"`
+	ReintentoFactor    float64  `json:"reintentoFactor"`
+	ReintentoJitter    *float64 `json:"reintentoJitter"`
+
+	BandaMuerta *float64 `json:"bandaMuerta"`
+	LatidoMs    *int     `json:"latidoMs"`
+
+	Capacidad float64 `json:"capacidad"`
+	Minimo    float64 `json:"minimo"`
+}
+
+// NewScale creates the Config for one entry of the scales file
+func NewScale(env Environment, def ScaleDefinition) (*Config, error) {
+	if !validScaleID.MatchString(def.ID) {
+		return nil, fmt.Errorf("id de báscula inválido: %q", def.ID)
+	}
+
+	c := New(env)
+	c.ID = def.ID
+	if def.Puerto != "" {
+		c.Puerto = def.Puerto
+	}
+	if def.Marca != "" {
+		c.Marca = def.Marca
+	}
+	c.ModoPrueba = def.ModoPrueba
+	c.Escenario = def.Escenario
+	c.Captura = def.Captura
+
+	serial := SerialSettings{
+		BaudRate:    def.Baudios,
+		DataBits:    def.BitsDatos,
+		Parity:      def.Paridad,
+		StopBits:    def.BitsParada,
+		ReadTimeout: time.Duration(def.TimeoutLecturaMs) * time.Millisecond,
+	}.Merge(c.Serial)
+	if err := serial.Validate(); err != nil {
+		return nil, fmt.Errorf("báscula %s: %w", def.ID, err)
+	}
+	c.Serial = serial
+
+	mode, err := ParseReadMode(def.ModoLectura)
+	if err != nil {
+		return nil, fmt.Errorf("báscula %s: %w", def.ID, err)
+	}
+	c.ModoLectura = mode
+
+	if def.VentanaEstabilidad != 0 {
+		c.Estabilidad.Window = def.VentanaEstabilidad
+	}
+	if def.ToleranciaEstabilidad != nil {
+		c.Estabilidad.Tolerance = *def.ToleranciaEstabilidad
+	}
+	c.Estabilidad.StableOnly = def.SoloEstable
+	if err := c.Estabilidad.Validate(); err != nil {
+		return nil, fmt.Errorf("báscula %s: %w", def.ID, err)
+	}
+	if !(def.Division >= 0) || math.IsInf(def.Division, 0) {
+		return nil, fmt.Errorf("báscula %s: división inválida: %v", def.ID, def.Division)
+	}
+	c.Division = def.Division
+	c.Unidad = def.Unidad
+
+	if def.ReintentoInicialMs != 0 {
+		c.Reintento.Initial = time.Duration(def.ReintentoInicialMs) * time.Millisecond
+	}
+	if def.ReintentoMaxMs != 0 {
+		c.Reintento.Max = time.Duration(def.ReintentoMaxMs) * time.Millisecond
+	}
+	if def.ReintentoFactor != 0 {
+		c.Reintento.Multiplier = def.ReintentoFactor
+	}
+	if def.ReintentoJitter != nil {
+		c.Reintento.Jitter = *def.ReintentoJitter
+	}
+	if err := c.Reintento.Validate(); err != nil {
+		return nil, fmt.Errorf("báscula %s: %w", def.ID, err)
+	}
+
+	if def.BandaMuerta != nil {
+		c.Publicacion.Deadband = *def.BandaMuerta
+	}
+	if def.LatidoMs != nil {
+		c.Publicacion.Heartbeat = time.Duration(*def.LatidoMs) * time.Millisecond
+	}
+	if err := c.Publicacion.Validate(); err != nil {
+		return nil, fmt.Errorf("báscula %s: %w", def.ID, err)
+	}
+
+	c.Rango = RangeSettings{Capacity: def.Capacidad, Minimum: def.Minimo}
+	if err := c.Rango.Validate(); err != nil {
+		return nil, fmt.Errorf("báscula %s: %w", def.ID, err)
+	}
+
+	return c, nil
+}
+
+// LoadScales reads the scales file at path. The first scale is the
+// default one streamed on /ws. A missing file returns nil and no error.
+func LoadScales(path string, env Environment) ([]*Config, error) {
+	//nolint:gosec
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var defs []ScaleDefinition
+	if err := json.Unmarshal(data, &defs); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(defs) == 0 {
+		return nil, fmt.Errorf("%s: no define ninguna báscula", path)
+	}
+
+	ids := make(map[string]bool)
+	scales := make([]*Config, 0, len(defs))
+	snapshots := make([]Snapshot, 0, len(defs))
+	for _, def := range defs {
+		c, err := NewScale(env, def)
+		if err != nil {
+			return nil, err
+		}
+		if ids[c.ID] {
+			return nil, fmt.Errorf("id de báscula duplicado: %q", c.ID)
+		}
+		ids[c.ID] = true
+		scales = append(scales, c)
+		snapshots = append(snapshots, c.Get())
+	}
+	if err := CheckPorts(snapshots); err != nil {
+		return nil, err
+	}
+	return scales, nil
+}
+
+// CheckPorts returns an error when two scales outside test mode read the
+// same port: two readers cannot share a serial port. Names compare
+// case-insensitively, as on Windows.
+func CheckPorts(scales []Snapshot) error {
+	ports := make(map[string]string)
+	for _, c := range scales {
+		if c.ModoPrueba {
+			continue
+		}
+		port := strings.ToUpper(c.Puerto)
+		if other, ok := ports[port]; ok {
+			return fmt.Errorf("las básculas %s y %s usan el mismo puerto %s", other, c.ID, c.Puerto)
+		}
+		ports[port] = c.ID
+	}
+	return nil
+}
@@ -7,12 +7,15 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"path/filepath"
 	"sync"
 	"time"
 
 	"github.com/judwhite/go-svc"
 
 	"github.com/adcondev/scale-daemon/internal/auth"
+	"github.com/adcondev/scale-daemon/internal/calibration"
 	"github.com/adcondev/scale-daemon/internal/config"
 	"github.com/adcondev/scale-daemon/internal/logging"
 	"github.com/adcondev/scale-daemon/internal/scale"
@@ -28,20 +31,25 @@ type Service struct {
 	timeStart        time.Time
 
 	// Components
-	env         config.Environment
-	cfg         *config.Config
-	logMgr      *logging.Manager
-	reader      *scale.Reader
-	broadcaster *server.Broadcaster
-	srv         *server.Server
-	authMgr     *auth.Manager
+	env     config.Environment
+	configs []*config.Config // one per scale, default first
+	scales  []*scaleUnit
+	logMgr  *logging.Manager
+	srv     *server.Server
+	authMgr *auth.Manager
 
 	// Lifecycle
-	broadcast chan string
-	wg        sync.WaitGroup
-	quit      chan struct{}
-	ctx       context.Context
-	cancel    context.CancelFunc
+	wg     sync.WaitGroup
+	quit   chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// scaleUnit holds the components serving one configured scale
+type scaleUnit struct {
+	reader      *scale.Reader
+	broadcaster *server.Broadcaster
+	broadcast   chan scale.Event
 }
 
 // New creates a new service instance
@@ -50,7 +58,6 @@ func New(buildEnv, buildDate, buildTime string) *Service {
 		BuildEnvironment: buildEnv,
 		BuildDate:        buildDate,
 		BuildTime:        buildTime,
-		broadcast:        make(chan string, 100),
 	}
 }
 
@@ -71,12 +78,60 @@ func (s *Service) Init(_ svc.Environment) error {
 	log.Printf("[i] Build: %s %s", s.BuildDate, s.BuildTime)
 	log.Printf("[i] Verbose: %v", s.logMgr.GetVerbose())
 
-	// Initialize config
-	s.cfg = config.New(s.env)
+	// Captures, replays and scenarios are only read and written here, so
+	// clients cannot name arbitrary paths
+	scale.CaptureDir = filepath.Join(os.Getenv("PROGRAMDATA"), s.env.ServiceName, "capturas")
+	if err := os.MkdirAll(scale.CaptureDir, 0o700); err != nil {
+		log.Printf("[X] No se pudo crear %s: %v", scale.CaptureDir, err)
+	}
+
+	// Initialize config: one per scale listed in the scales file, or the
+	// single default scale
+	s.configs = s.loadScales()
 
 	return nil
 }
 
+// loadScales reads the scales file next to the log file. A missing or
+// invalid file falls back to the single default scale.
+func (s *Service) loadScales() []*config.Config {
+	path := filepath.Join(os.Getenv("PROGRAMDATA"), s.env.ServiceName, config.ScalesFile)
+	configs, err := config.LoadScales(path, s.env)
+	if err != nil {
+		log.Printf("[X] Error en %s: %v. Usando báscula por defecto", path, err)
+		configs = nil
+	}
+	if len(configs) == 0 {
+		return []*config.Config{config.New(s.env)}
+	}
+
+	for _, cfg := range configs {
+		conf := cfg.Get()
+		if _, err := scale.LookupDriver(conf.Marca); err != nil {
+			log.Printf("[!] Báscula %s: %v", conf.ID, err)
+		}
+		if conf.Escenario != "" {
+			if _, err := scale.LoadNamedScenario(conf.Escenario); err != nil {
+				log.Printf("[!] Báscula %s: %v", conf.ID, err)
+			}
+		}
+		if conf.Unidad != "" {
+			unit, err := scale.ParseUnit(conf.Unidad)
+			if err != nil {
+				log.Printf("[!] Báscula %s: %v. Lecturas sin unidad", conf.ID, err)
+			}
+			cfg.UpdateUnit(unit)
+		}
+		if conf.Captura != "" {
+			if err := scale.CheckCapture(conf.Captura); err != nil {
+				log.Printf("[!] Báscula %s: %v", conf.ID, err)
+			}
+		}
+		log.Printf("[i] Báscula %s: %s (%s) en %s", conf.ID, conf.Marca, conf.Serial, conf.Puerto)
+	}
+	return configs
+}
+
 // Start implements svc.Service
 func (s *Service) Start() error {
 	s.quit = make(chan struct{})
@@ -85,24 +140,47 @@ func (s *Service) Start() error {
 	// Create auth manager (bound to service ctx for clean shutdown)
 	s.authMgr = auth.NewManager(s.ctx)
 
-	// Create broadcaster with weight activity callback
-	s.broadcaster = server.NewBroadcaster(s.broadcast, func() {
-		s.srv.RecordWeightActivity()
-	})
+	// Verification reports are stored next to the scales file. The signing
+	// key stays outside the reports directory so access to the reports
+	// does not give access to the key.
+	dataDir := filepath.Join(os.Getenv("PROGRAMDATA"), s.env.ServiceName)
+	calibrationDir := filepath.Join(dataDir, "calibraciones")
+	calibrationMgr, err := calibration.NewManager(calibrationDir, filepath.Join(dataDir, calibration.KeyFile))
+	if err != nil {
+		log.Printf("[X] Verificación no disponible en %s: %v", calibrationDir, err)
+		calibrationMgr = nil
+	}
+
+	// Create a reader and a broadcaster per scale
+	s.scales = make([]*scaleUnit, 0, len(s.configs))
+	srvScales := make([]*server.Scale, 0, len(s.configs))
+	for _, cfg := range s.configs {
+		unit := &scaleUnit{broadcast: make(chan scale.Event, 100)}
+		unit.reader = scale.NewReader(cfg, unit.broadcast)
+
+		srvScale := &server.Scale{
+			Config:         cfg,
+			Operator:       unit.reader,
+			OnConfigChange: unit.onConfigChange,
+		}
+		// Broadcaster for weights and reader state changes
+		states, _ := unit.reader.Subscribe(16)
+		unit.broadcaster = server.NewBroadcaster(unit.broadcast, states)
+		srvScale.Broadcaster = unit.broadcaster
 
-	// Create scale reader
-	s.reader = scale.NewReader(s.cfg, s.broadcast)
+		s.scales = append(s.scales, unit)
+		srvScales = append(srvScales, srvScale)
+	}
 
 	// Create HTTP/WebSocket server
 	buildInfo := fmt.Sprintf("%s %s", s.BuildDate, s.BuildTime)
 	s.srv = server.NewServer(
-		s.cfg,
+		srvScales,
 		s.env,
-		s.broadcaster,
 		s.logMgr,
 		s.authMgr,
+		calibrationMgr,
 		buildInfo,
-		s.onConfigChange,
 		s.BuildDate,
 		s.BuildTime,
 		s.timeStart,
@@ -122,11 +200,11 @@ func (s *Service) run() {
 	log.Printf("[i] Servidor BASCULA - Ambiente: %s", s.env.Name)
 	log.Printf("[i] Build: %s %s", s.BuildDate, s.BuildTime)
 
-	// Start broadcaster
-	go s.broadcaster.Start(s.ctx)
-
-	// Start scale reader
-	go s.reader.Start(s.ctx)
+	// Start broadcasters and scale readers
+	for _, unit := range s.scales {
+		go unit.broadcaster.Start(s.ctx)
+		go unit.reader.Start(s.ctx)
+	}
 
 	// Start HTTP server
 	go func() {
@@ -157,8 +235,10 @@ func (s *Service) Stop() error {
 	// 1. Cancel the context (signals broadcaster and reader)
 	s.cancel()
 
-	// 2. Stop the serial reader
-	s.reader.Stop()
+	// 2. Stop the serial readers
+	for _, unit := range s.scales {
+		unit.reader.Stop()
+	}
 
 	// 3. Gracefully shut down the HTTP/WS server (with timeout)
 	// This causes ListenAndServe() to return with http.ErrServerClosed
@@ -192,8 +272,8 @@ func (s *Service) Stop() error {
 	return nil
 }
 
-// onConfigChange is called when config changes via WebSocket
-func (s *Service) onConfigChange() {
+// onConfigChange is called when a scale's config changes via WebSocket
+func (u *scaleUnit) onConfigChange() {
 	log.Println("[.] Cerrando puerto serial...")
-	s.reader.ClosePort()
+	u.reader.ClosePort()
 }
@@ -0,0 +1,250 @@
+package scale
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Capture files hold one line per transfer: seconds since the capture
+// started, TX (daemon to scale) or RX (scale to daemon) and the bytes in
+// hex. Lines starting with # are notes.
+//
+//	# scale-daemon capture v1
+//	# puerto=COM3 marca=Rhino BAR 8RS linea=9600 8N1 inicio=2026-03-02T10:15:00-06:00
+//	0.000000 TX 50
+//	0.041237 RX 312e35300d
+const captureHeader = "# scale-daemon capture v1"
+
+// Capture directions
+const (
+	captureTX = "TX"
+	captureRX = "RX"
+)
+
+// captureEntry is one recorded transfer
+type captureEntry struct {
+	Offset time.Duration
+	Dir    string
+	Data   []byte
+}
+
+// errNotCapturing is returned by stopCapture when no capture is running
+var errNotCapturing = errors.New("no hay captura activa")
+
+// CaptureDir holds capture files, replay:// captures and simulation
+// scenarios. The daemon sets it to %PROGRAMDATA%\<service>\capturas.
+// Empty rejects every file name.
+var CaptureDir string
+
+// ErrInvalidFileName is returned by ResolveFile for a name that is not a
+// bare file name
+var ErrInvalidFileName = errors.New("nombre de archivo inválido")
+
+// ErrInvalidCapture is returned by CheckCapture for an unusable name
+var ErrInvalidCapture = errors.New("archivo de captura inválido")
+
+// ResolveFile returns the path under CaptureDir of a capture or scenario
+// file. Names come from WebSocket clients, so only bare file names are
+// accepted: no separators, drive letters or "..".
+func ResolveFile(name string) (string, error) {
+	if CaptureDir == "" {
+		return "", fmt.Errorf("%w: directorio de capturas no configurado", ErrInvalidFileName)
+	}
+	if name == "" || strings.ContainsAny(name, `/\:`) || strings.Contains(name, "..") || filepath.Base(name) != name {
+		return "", fmt.Errorf("%w: %q", ErrInvalidFileName, name)
+	}
+	return filepath.Join(CaptureDir, name), nil
+}
+
+// CheckCapture reports whether name can hold a new capture: a valid file
+// name that does not exist yet in CaptureDir. Captures never overwrite a
+// file.
+func CheckCapture(name string) error {
+	path, err := ResolveFile(name)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidCapture, err)
+	}
+	if _, err := os.Lstat(path); !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%w: %s ya existe", ErrInvalidCapture, name)
+	}
+	return nil
+}
+
+// captureRecorder appends transfers to the capture file, if any. The zero
+// value records nothing.
+type captureRecorder struct {
+	mu    sync.Mutex
+	f     *os.File
+	path  string
+	start time.Time
+}
+
+func (c *captureRecorder) open(path, header string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600) //nolint:gosec // resolved under CaptureDir
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	if _, err := fmt.Fprintf(f, "%s\n# %s inicio=%s\n", captureHeader, header, start.Format(time.RFC3339)); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.f != nil {
+		_ = c.f.Close()
+	}
+	c.f, c.path, c.start = f, path, start
+	return nil
+}
+
+func (c *captureRecorder) current() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.path
+}
+
+func (c *captureRecorder) close() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.f == nil {
+		return "", errNotCapturing
+	}
+	err := c.f.Close()
+	path := c.path
+	c.f, c.path = nil, ""
+	return path, err
+}
+
+func (c *captureRecorder) record(dir string, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.f == nil || len(data) == 0 {
+		return
+	}
+	offset := time.Since(c.start).Seconds()
+	_, _ = fmt.Fprintf(c.f, "%.6f %s %s\n", offset, dir, hex.EncodeToString(data))
+}
+
+func (c *captureRecorder) note(format string, args ...any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.f == nil {
+		return
+	}
+	_, _ = fmt.Fprintf(c.f, "# %.6f %s\n", time.Since(c.start).Seconds(), fmt.Sprintf(format, args...))
+}
+
+// capturePort records every transfer of the wrapped port
+type capturePort struct {
+	Port
+	rec *captureRecorder
+}
+
+func (p *capturePort) Read(b []byte) (int, error) {
+	n, err := p.Port.Read(b)
+	if n > 0 {
+		p.rec.record(captureRX, b[:n])
+	}
+	return n, err
+}
+
+func (p *capturePort) Write(b []byte) (int, error) {
+	n, err := p.Port.Write(b)
+	if n > 0 {
+		p.rec.record(captureTX, b[:n])
+	}
+	return n, err
+}
+
+// startCapture records the raw bytes exchanged with the scale to a new file
+// in CaptureDir, replacing any capture in progress. The file can be played
+// back with a replay:// puerto.
+func (r *Reader) startCapture(name string) error {
+	path, err := ResolveFile(name)
+	if err != nil {
+		return err
+	}
+	conf := r.config.Get()
+	header := fmt.Sprintf("puerto=%s marca=%s linea=%s", conf.Puerto, conf.Marca, conf.Serial)
+	if err := r.capture.open(path, header); err != nil {
+		return err
+	}
+	log.Printf("[i] Capturando tráfico serial de %s en %s", conf.ID, path)
+	return nil
+}
+
+// stopCapture closes the capture file and returns its path
+func (r *Reader) stopCapture() (string, error) {
+	path, err := r.capture.close()
+	if err == nil {
+		log.Printf("[i] Captura finalizada: %s", path)
+	}
+	return path, err
+}
+
+// syncCapture starts or stops the capture to follow the captura setting.
+// It runs before every connection, so a capture always starts with the
+// port being opened and plays back from the first exchange.
+func (r *Reader) syncCapture(name string) {
+	current := r.capture.current()
+	if name == "" {
+		if current != "" {
+			_, _ = r.stopCapture()
+		}
+		return
+	}
+	if path, err := ResolveFile(name); err == nil && path == current {
+		return
+	}
+	if err := r.startCapture(name); err != nil {
+		log.Printf("[X] No se pudo iniciar la captura %s: %v", name, err)
+	}
+}
+
+// loadCapture reads a capture file
+func loadCapture(path string) ([]captureEntry, error) {
+	f, err := os.Open(path) //nolint:gosec // resolved under CaptureDir
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var entries []captureEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 3 || (fields[1] != captureTX && fields[1] != captureRX) {
+			return nil, fmt.Errorf("%s:%d: línea de captura inválida", path, lineNo)
+		}
+		seconds, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNo, err)
+		}
+		data, err := hex.DecodeString(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNo, err)
+		}
+		entries = append(entries, captureEntry{
+			Offset: time.Duration(seconds * float64(time.Second)),
+			Dir:    fields[1],
+			Data:   data,
+		})
+	}
+	return entries, scanner.Err()
+}
@@ -0,0 +1,203 @@
+package scale
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.bug.st/serial"
+
+	"github.com/adcondev/scale-daemon/internal/config"
+)
+
+// useCaptureDir points CaptureDir at a temporary directory for the test
+func useCaptureDir(t *testing.T) string {
+	t.Helper()
+	orig := CaptureDir
+	CaptureDir = t.TempDir()
+	t.Cleanup(func() { CaptureDir = orig })
+	return CaptureDir
+}
+
+func TestCaptureRecordsAndReplays(t *testing.T) {
+	origSerialOpen := serialOpen
+	defer func() { serialOpen = origSerialOpen }()
+	serialOpen = func(_ string, _ *serial.Mode) (Port, error) {
+		return &scriptedPort{replies: map[string]string{"SI\r\n": "S S      2.500 kg\r\n"}}, nil
+	}
+
+	path := filepath.Join(useCaptureDir(t), "bascula.cap")
+	cfg := config.New(config.Environment{DefaultPort: "COM_TEST"})
+	cfg.UpdateCapture("bascula.cap")
+	r := NewReader(cfg, make(chan Event, 10))
+	if err := r.connect("COM_TEST", config.DefaultSerialSettings()); err != nil {
+		t.Fatalf("connect() error: %v", err)
+	}
+	port := r.currentPort()
+	buf := make([]byte, 64)
+	_, _ = port.Write([]byte("SI\r\n"))
+	_, _ = port.Read(buf)
+	if _, err := r.stopCapture(); err != nil {
+		t.Fatalf("stopCapture() error: %v", err)
+	}
+	if _, err := r.stopCapture(); err != errNotCapturing {
+		t.Errorf("Second stopCapture() error = %v, want errNotCapturing", err)
+	}
+
+	entries, err := loadCapture(path)
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("loadCapture() = %+v, %v", entries, err)
+	}
+	if entries[0].Dir != captureTX || string(entries[1].Data) != "S S      2.500 kg\r\n" {
+		t.Errorf("Captured %+v", entries)
+	}
+
+	// Playback waits at the TX sync point until the daemon polls
+	replay, err := openPort(ReplayScheme+"bascula.cap?speed=0", config.DefaultSerialSettings())
+	if err != nil {
+		t.Fatalf("openPort(replay) error: %v", err)
+	}
+	defer func() { _ = replay.Close() }()
+	_ = replay.SetReadTimeout(30 * time.Millisecond)
+	if n, err := replay.Read(buf); n != 0 || err != nil {
+		t.Errorf("Read() before poll = %d, %v, want 0, nil", n, err)
+	}
+	_, _ = replay.Write([]byte("SI\r\n"))
+	if n, err := replay.Read(buf); err != nil || string(buf[:n]) != "S S      2.500 kg\r\n" {
+		t.Errorf("Read() after poll = %q, %v", buf[:n], err)
+	}
+	// The capture is over: the scale is silent
+	if n, err := replay.Read(buf); n != 0 || err != nil {
+		t.Errorf("Read() past the end = %d, %v, want 0, nil", n, err)
+	}
+}
+
+func TestReaderCaptureFollowsConfig(t *testing.T) {
+	origSerialOpen := serialOpen
+	defer func() { serialOpen = origSerialOpen }()
+	serialOpen = func(_ string, _ *serial.Mode) (Port, error) {
+		return &scriptedPort{replies: map[string]string{"SI\r\n": "S S      1.000 kg\r\n"}}, nil
+	}
+
+	path := filepath.Join(useCaptureDir(t), "mostrador.cap")
+	cfg := config.New(config.Environment{DefaultPort: "COM_TEST"})
+	cfg.Update("", "MT-SICS", false)
+	cfg.UpdateCapture("mostrador.cap")
+	retry := config.DefaultRetrySettings()
+	retry.Initial, retry.Jitter = 100*time.Millisecond, 0
+	if _, err := cfg.UpdateRetry(retry); err != nil {
+		t.Fatal(err)
+	}
+	broadcast := make(chan Event, 100)
+	r := NewReader(cfg, broadcast)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.Start(ctx)
+
+	select {
+	case <-broadcast:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for a reading")
+	}
+
+	// Clearing the setting stops the capture on reconnect, as a config
+	// message does
+	cfg.UpdateCapture("")
+	r.ClosePort()
+	deadline := time.Now().Add(2 * time.Second)
+	for r.capture.current() != "" {
+		if time.Now().After(deadline) {
+			t.Fatal("Capture still running after clearing captura")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	entries, err := loadCapture(path)
+	if err != nil || len(entries) < 2 {
+		t.Fatalf("loadCapture() = %+v, %v", entries, err)
+	}
+	if entries[0].Dir != captureTX || string(entries[0].Data) != "SI\r\n" || string(entries[1].Data) != "S S      1.000 kg\r\n" {
+		t.Errorf("Captured %+v", entries[:2])
+	}
+
+	// Clients only name files in CaptureDir, and never an existing one
+	for _, bad := range []string{"", "mostrador.cap", path, "../x.cap", "sub/x.cap", `sub\x.cap`, `C:x.cap`, ".."} {
+		if err := CheckCapture(bad); !errors.Is(err, ErrInvalidCapture) {
+			t.Errorf("CheckCapture(%q) = %v, want ErrInvalidCapture", bad, err)
+		}
+	}
+	if err := CheckCapture("nueva.cap"); err != nil {
+		t.Errorf("CheckCapture(nueva.cap) = %v", err)
+	}
+	if err := r.startCapture("mostrador.cap"); err == nil {
+		t.Error("startCapture() overwrote an existing capture")
+	}
+}
+
+func TestReplayKeepsTiming(t *testing.T) {
+	path := filepath.Join(useCaptureDir(t), "continuo.cap")
+	capture := captureHeader + "\n0.000000 RX 41\n0.200000 RX 42\n"
+	if err := os.WriteFile(path, []byte(capture), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	port, err := openPort(ReplayScheme+"continuo.cap?speed=2", config.DefaultSerialSettings())
+	if err != nil {
+		t.Fatalf("openPort(replay) error: %v", err)
+	}
+	defer func() { _ = port.Close() }()
+	_ = port.SetReadTimeout(time.Second)
+
+	buf := make([]byte, 8)
+	var got []byte
+	start := time.Now()
+	for len(got) < 2 {
+		n, err := port.Read(buf)
+		if err != nil {
+			t.Fatalf("Read() error: %v", err)
+		}
+		got = append(got, buf[:n]...)
+	}
+	elapsed := time.Since(start)
+	if !bytes.Equal(got, []byte("AB")) {
+		t.Errorf("Replayed %q, want AB", got)
+	}
+	// 0.2 s recorded at double speed
+	if elapsed < 80*time.Millisecond || elapsed > 300*time.Millisecond {
+		t.Errorf("Replay took %v, want about 100ms", elapsed)
+	}
+
+	for _, puerto := range []string{"replay://", "replay://continuo.cap?speed=-1", "replay://missing.cap", "replay://" + path, "replay://../continuo.cap"} {
+		if _, err := openPort(puerto, config.DefaultSerialSettings()); err == nil {
+			t.Errorf("openPort(%q) expected an error", puerto)
+		}
+	}
+}
+
+func TestReaderRunsAgainstReplay(t *testing.T) {
+	path := filepath.Join(useCaptureDir(t), "rhino.cap")
+	capture := captureHeader + "\n# puerto=COM3 marca=Rhino BAR 8RS\n0.000000 TX 50\n0.040000 RX 31322e33340d\n"
+	if err := os.WriteFile(path, []byte(capture), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.New(config.Environment{DefaultPort: ReplayScheme + "rhino.cap?speed=0&loop=1"})
+	broadcast := make(chan Event, 10)
+	r := NewReader(cfg, broadcast)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.Start(ctx)
+
+	select {
+	case ev := <-broadcast:
+		if ev.String() != "12.34" {
+			t.Errorf("Published %q from replay, want 12.34", ev)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for a replayed reading")
+	}
+}
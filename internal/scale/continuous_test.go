@@ -0,0 +1,113 @@
+package scale
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"go.bug.st/serial"
+
+	"github.com/adcondev/scale-daemon/internal/config"
+)
+
+// streamPort blocks on Read until data is pushed or the port is closed,
+// like a serial port attached to a continuously streaming indicator.
+type streamPort struct {
+	data      chan []byte
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+func newStreamPort() *streamPort {
+	return &streamPort{data: make(chan []byte, 100), closed: make(chan struct{})}
+}
+
+func (p *streamPort) Read(b []byte) (int, error) {
+	select {
+	case chunk := <-p.data:
+		return copy(b, chunk), nil
+	case <-p.closed:
+		return 0, io.ErrClosedPipe
+	}
+}
+
+func (p *streamPort) Write(b []byte) (int, error) { return len(b), nil }
+
+func (p *streamPort) Close() error {
+	p.closeOnce.Do(func() { close(p.closed) })
+	return nil
+}
+
+func (p *streamPort) SetReadTimeout(_ time.Duration) error { return nil }
+
+func TestResolveReadMode(t *testing.T) {
+	tests := []struct {
+		configured config.ReadMode
+		driver     Driver
+		want       config.ReadMode
+	}{
+		{config.ReadModeAuto, rhinoDriver{}, config.ReadModePoll},
+		{config.ReadModeAuto, toledoDriver{}, config.ReadModeContinuous},
+		{config.ReadModeContinuous, rhinoDriver{}, config.ReadModeContinuous},
+		{config.ReadModePoll, toledoDriver{}, config.ReadModeContinuous},
+		{config.ReadModePoll, sicsDriver{}, config.ReadModePoll},
+	}
+	for _, tt := range tests {
+		if got := resolveReadMode(tt.configured, tt.driver); got != tt.want {
+			t.Errorf("resolveReadMode(%s, %s) = %s, want %s", tt.configured, tt.driver.Name(), got, tt.want)
+		}
+	}
+}
+
+func TestContinuousModeKeepsLatestFrame(t *testing.T) {
+	cfg := config.New(config.Environment{DefaultPort: "COM_TEST"})
+	cfg.Update("", "Toledo 8142", false)
+
+	port := newStreamPort()
+	origSerialOpen := serialOpen
+	defer func() { serialOpen = origSerialOpen }()
+	serialOpen = func(_ string, _ *serial.Mode) (Port, error) {
+		return port, nil
+	}
+
+	broadcast := make(chan Event, 100)
+	r := NewReader(cfg, broadcast)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		r.Start(ctx)
+		close(done)
+	}()
+
+	// A burst of frames arriving in a single read: only the last is published
+	var burst []byte
+	for _, w := range []string{"000100", "000200", "000300"} {
+		burst = append(burst, toledoFrame(0x2C, 0x30, 0x20, w, "000000")...)
+		burst = append(burst, cr)
+	}
+	burst = append(burst, stx) // confirms the last frame has no checksum
+	port.data <- burst
+
+	select {
+	case got := <-broadcast:
+		if got.String() != "3.00" {
+			t.Errorf("Published %q, want latest frame 3.00", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for continuous reading")
+	}
+
+	// Canceling must interrupt the blocked read promptly
+	start := time.Now()
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("Reader did not stop after context cancellation")
+	}
+	if d := time.Since(start); d > 200*time.Millisecond {
+		t.Errorf("Reader took %v to stop", d)
+	}
+}
@@ -0,0 +1,203 @@
+package scale
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultBrand is the brand used when the configuration does not name one.
+const DefaultBrand = "Rhino BAR 8RS"
+
+// Driver encapsulates the wire protocol of a scale indicator family:
+// the bytes written to request a weight, how responses are delimited
+// and how a single response is turned into a Reading.
+type Driver interface {
+	// Name returns the canonical brand name of the driver.
+	Name() string
+	// Command returns the bytes written to the port to request a weight reading.
+	// Drivers for indicators that stream continuously return nil.
+	Command() []byte
+	// Framing describes how responses are delimited on the wire.
+	Framing() Framing
+	// Parse converts a single response frame into a Reading. Frames that
+	// carry no weight must return an error wrapping ErrUnparsable.
+	Parse(frame []byte) (Reading, error)
+}
+
+// Operation is an indicator command beyond the weight request
+type Operation string
+
+// Operations that drivers may support
+const (
+	// OpTare stores the current weight as tare.
+	OpTare Operation = "tare"
+	// OpZero sets the current weight as the zero point.
+	OpZero Operation = "zero"
+	// OpClearTare removes the active tare.
+	OpClearTare Operation = "clearTare"
+	// OpPresetTare stores a known tare value sent by the client.
+	OpPresetTare Operation = "presetTare"
+	// OpSerialNumber queries the indicator's serial number.
+	OpSerialNumber Operation = "serialNumber"
+)
+
+// OperationParams are the arguments of an Operation. Only OpPresetTare
+// takes any: the tare Value in Unit.
+type OperationParams struct {
+	Value float64
+	Unit  string
+}
+
+// ErrUnsupportedOperation is returned when a driver cannot perform an operation
+var ErrUnsupportedOperation = errors.New("operación no soportada por el driver")
+
+// OperationResult is the indicator's reply to an Operation
+type OperationResult struct {
+	Op Operation
+	// Value and Unit carry the tare stored by tare operations.
+	Value float64
+	Unit  string
+	// Text carries textual replies such as the serial number.
+	Text string
+	// Software is true when the reader performed the operation itself
+	// because the indicator does not support it.
+	Software bool
+}
+
+// Operator is implemented by drivers whose indicators accept operations
+// beyond the weight request.
+type Operator interface {
+	// OperationCommand returns the bytes written to perform op, or an error
+	// wrapping ErrUnsupportedOperation.
+	OperationCommand(op Operation, params OperationParams) ([]byte, error)
+	// ParseOperation interprets the indicator's reply to op.
+	ParseOperation(op Operation, frame []byte) (OperationResult, error)
+}
+
+// Encoder is implemented by drivers that can also play the indicator side
+// of the protocol. The scale emulator uses it so both ends of the wire
+// share one definition.
+type Encoder interface {
+	// Encode returns the frame the indicator sends for r, including any
+	// start marker, terminator and checksum.
+	Encode(r Reading) []byte
+}
+
+// OperationEncoder is implemented by encoders whose indicators answer
+// operation commands.
+type OperationEncoder interface {
+	// EncodeOperation returns the indicator's reply to a successful
+	// operation.
+	EncodeOperation(res OperationResult) []byte
+}
+
+// OperationDecoder is implemented by operation encoders whose indicators
+// take operations with parameters, such as a preset tare. The emulator uses
+// it to read the command line back.
+type OperationDecoder interface {
+	// OperationPrefix returns how op's command line starts, or nil when op
+	// takes no parameters. The line ends with '\n'.
+	OperationPrefix(op Operation) []byte
+	// DecodeOperation returns the parameters of op's command line, without
+	// its terminator.
+	DecodeOperation(op Operation, line []byte) (OperationParams, error)
+	// EncodeOperationError returns the indicator's rejection of op.
+	EncodeOperationError(op Operation) []byte
+}
+
+// StatusError is an indicator-reported condition that maps to a
+// broadcast error code.
+type StatusError struct {
+	// Code is the ERR_* code broadcast to clients.
+	Code string
+	// Detail describes the condition as reported by the indicator.
+	Detail string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Detail)
+}
+
+var (
+	driversMu sync.RWMutex
+	drivers   = map[string]Driver{}
+)
+
+// RegisterDriver makes a driver available under its name and any aliases.
+// Names are matched case-insensitively.
+func RegisterDriver(d Driver, aliases ...string) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+	for _, name := range append([]string{d.Name()}, aliases...) {
+		drivers[strings.ToLower(strings.TrimSpace(name))] = d
+	}
+}
+
+// LookupDriver returns the driver registered for a brand
+func LookupDriver(brand string) (Driver, error) {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+	if d, ok := drivers[strings.ToLower(strings.TrimSpace(brand))]; ok {
+		return d, nil
+	}
+	return nil, fmt.Errorf("marca de báscula desconocida: %q", brand)
+}
+
+// Brands returns the canonical names of all registered drivers, sorted
+func Brands() []string {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+	seen := make(map[string]bool)
+	var names []string
+	for _, d := range drivers {
+		if !seen[d.Name()] {
+			seen[d.Name()] = true
+			names = append(names, d.Name())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// driverFor resolves the driver for a brand, falling back to the default
+// brand with a logged warning so a bad config never stops the read loop.
+func driverFor(brand string) (Driver, error) {
+	d, err := LookupDriver(brand)
+	if err == nil {
+		return d, nil
+	}
+	fallback, fbErr := LookupDriver(DefaultBrand)
+	if fbErr != nil {
+		return nil, err
+	}
+	return fallback, err
+}
+
+func init() {
+	RegisterDriver(rhinoDriver{}, "rhino")
+}
+
+// rhinoDriver implements the Rhino BAR 8RS poll protocol: a single "P"
+// request answered with the weight as plain ASCII.
+type rhinoDriver struct{}
+
+func (rhinoDriver) Name() string { return DefaultBrand }
+
+func (rhinoDriver) Command() []byte { return []byte("P") }
+
+// Some Rhino firmwares omit the CR, so a response is also complete once
+// the line goes idle after the poll.
+func (rhinoDriver) Framing() Framing {
+	return Framing{Terminator: []byte{cr}, FlushOnIdle: true}
+}
+
+func (rhinoDriver) Parse(frame []byte) (Reading, error) {
+	return parseASCIIWeight(frame)
+}
+
+func (rhinoDriver) Encode(r Reading) []byte {
+	return append([]byte(r.String()), cr)
+}
@@ -0,0 +1,43 @@
+package scale
+
+import (
+	"testing"
+)
+
+func TestLookupDriver(t *testing.T) {
+	for _, brand := range []string{"Rhino BAR 8RS", "rhino", "  RHINO  "} {
+		d, err := LookupDriver(brand)
+		if err != nil {
+			t.Fatalf("LookupDriver(%q) returned error: %v", brand, err)
+		}
+		if d.Name() != DefaultBrand {
+			t.Errorf("LookupDriver(%q) = %s, want %s", brand, d.Name(), DefaultBrand)
+		}
+	}
+
+	if _, err := LookupDriver("Marca Inexistente"); err == nil {
+		t.Error("Expected error for unknown brand")
+	}
+}
+
+func TestDriverForFallsBack(t *testing.T) {
+	d, err := driverFor("Marca Inexistente")
+	if err == nil {
+		t.Error("Expected error to be reported for unknown brand")
+	}
+	if d == nil || d.Name() != DefaultBrand {
+		t.Errorf("Expected fallback to %s, got %v", DefaultBrand, d)
+	}
+}
+
+func TestBrands(t *testing.T) {
+	found := false
+	for _, b := range Brands() {
+		if b == DefaultBrand {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Brands() does not include %s", DefaultBrand)
+	}
+}
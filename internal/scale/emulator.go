@@ -0,0 +1,256 @@
+package scale
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// EmulatorSerial is the serial number the emulator reports
+const EmulatorSerial = "EMU-0001"
+
+// emulatorCommand is a request the emulator recognizes. An empty op is the
+// weight request. With line set, cmd is only the start of a command whose
+// parameters run to the end of the line.
+type emulatorCommand struct {
+	cmd  []byte
+	op   Operation
+	line bool
+}
+
+// Emulator plays the indicator side of a driver's protocol, answering with
+// readings from a Simulator. Pause and error steps leave the indicator
+// silent. Indicators that accept operations keep their own zero and tare.
+type Emulator struct {
+	driver   Driver
+	encoder  Encoder
+	sim      *Simulator
+	commands []emulatorCommand
+
+	mu      sync.Mutex
+	current *Reading // nil while silent
+	zero    float64
+	tare    float64
+}
+
+// NewEmulator prepares an emulated indicator of the given brand
+func NewEmulator(brand string, s *Scenario) (*Emulator, error) {
+	d, err := LookupDriver(brand)
+	if err != nil {
+		return nil, err
+	}
+	enc, ok := d.(Encoder)
+	if !ok {
+		return nil, fmt.Errorf("la marca %s no puede emularse", d.Name())
+	}
+
+	e := &Emulator{driver: d, encoder: enc, sim: NewSimulator(s)}
+	if cmd := d.Command(); len(cmd) > 0 {
+		e.commands = append(e.commands, emulatorCommand{cmd: cmd})
+	}
+	if op, ok := d.(Operator); ok {
+		if _, ok := d.(OperationEncoder); ok {
+			for _, o := range []Operation{OpTare, OpClearTare, OpZero, OpSerialNumber} {
+				if cmd, err := op.OperationCommand(o, OperationParams{}); err == nil {
+					e.commands = append(e.commands, emulatorCommand{cmd: cmd, op: o})
+				}
+			}
+		}
+	}
+	if dec, ok := d.(OperationDecoder); ok {
+		if prefix := dec.OperationPrefix(OpPresetTare); prefix != nil {
+			e.commands = append(e.commands, emulatorCommand{cmd: prefix, op: OpPresetTare, line: true})
+		}
+	}
+	return e, nil
+}
+
+// Driver returns the emulated driver
+func (e *Emulator) Driver() Driver {
+	return e.driver
+}
+
+// Seed returns the simulator seed, to reproduce a run
+func (e *Emulator) Seed() uint64 {
+	return e.sim.Seed()
+}
+
+// Run plays the scenario until ctx is done
+func (e *Emulator) Run(ctx context.Context) {
+	for {
+		tick := e.sim.Next()
+		e.mu.Lock()
+		e.current = tick.Reading
+		e.mu.Unlock()
+
+		t := time.NewTimer(tick.Wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// Serve talks to one connection until it fails or ctx is done. Polled
+// indicators answer their commands; continuous ones stream a frame every
+// scenario interval. The caller closes rw.
+func (e *Emulator) Serve(ctx context.Context, rw io.ReadWriter) error {
+	var wmu sync.Mutex
+	write := func(b []byte) error {
+		wmu.Lock()
+		defer wmu.Unlock()
+		_, err := rw.Write(b)
+		return err
+	}
+
+	errc := make(chan error, 2)
+	if len(e.driver.Command()) == 0 {
+		go func() { errc <- e.stream(ctx, write) }()
+	}
+	go func() { errc <- e.answer(rw, write) }()
+
+	select {
+	case <-ctx.Done():
+		return nil
+	case err := <-errc:
+		return err
+	}
+}
+
+func (e *Emulator) stream(ctx context.Context, write func([]byte) error) error {
+	ticker := time.NewTicker(e.sim.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		if r, ok := e.reading(); ok {
+			if err := write(e.encoder.Encode(r)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// answer reads requests and replies to every recognized command. Unknown
+// bytes are dropped.
+func (e *Emulator) answer(r io.Reader, write func([]byte) error) error {
+	var pending []byte
+	buf := make([]byte, 256)
+	for {
+		n, err := r.Read(buf)
+		if err != nil {
+			return err
+		}
+		pending = append(pending, buf[:n]...)
+
+		for len(pending) > 0 {
+			cmd, n, partial := e.match(pending)
+			if cmd == nil {
+				if partial {
+					break
+				}
+				pending = pending[1:]
+				continue
+			}
+			request := pending[:n]
+			pending = pending[n:]
+			if reply := e.reply(cmd, request); reply != nil {
+				if err := write(reply); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// match returns the command at the start of data and its length, or
+// reports whether data may be the start of one
+func (e *Emulator) match(data []byte) (*emulatorCommand, int, bool) {
+	partial := false
+	for i, c := range e.commands {
+		if bytes.HasPrefix(data, c.cmd) {
+			if !c.line {
+				return &e.commands[i], len(c.cmd), false
+			}
+			if end := bytes.IndexByte(data, '\n'); end >= 0 {
+				return &e.commands[i], end + 1, false
+			}
+			partial = true
+		}
+		if bytes.HasPrefix(c.cmd, data) {
+			partial = true
+		}
+	}
+	return nil, 0, partial
+}
+
+// reply performs the command in request and returns the answer, nil when
+// silent
+func (e *Emulator) reply(cmd *emulatorCommand, request []byte) []byte {
+	op := cmd.op
+	if op == "" {
+		if r, ok := e.reading(); ok {
+			return e.encoder.Encode(r)
+		}
+		return nil
+	}
+	var params OperationParams
+	if cmd.line {
+		dec := e.driver.(OperationDecoder)
+		var err error
+		if params, err = dec.DecodeOperation(op, bytes.TrimRight(request, "\r\n")); err != nil {
+			return dec.EncodeOperationError(op)
+		}
+	}
+
+	e.mu.Lock()
+	res := OperationResult{Op: op}
+	if e.current == nil && op != OpSerialNumber {
+		e.mu.Unlock()
+		return nil
+	}
+	switch op {
+	case OpTare:
+		e.tare = e.current.Value - e.zero
+		res.Value, res.Unit = e.tare, e.current.Unit
+	case OpPresetTare:
+		if params.Unit != e.current.Unit {
+			e.mu.Unlock()
+			return e.driver.(OperationDecoder).EncodeOperationError(op)
+		}
+		e.tare = params.Value
+		res.Value, res.Unit = params.Value, params.Unit
+	case OpClearTare:
+		e.tare = 0
+	case OpZero:
+		e.zero, e.tare = e.current.Value, 0
+	case OpSerialNumber:
+		res.Text = EmulatorSerial
+	}
+	e.mu.Unlock()
+	return e.driver.(OperationEncoder).EncodeOperation(res)
+}
+
+// reading returns the current reading with zero and tare applied
+func (e *Emulator) reading() (Reading, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.current == nil {
+		return Reading{}, false
+	}
+	r := *e.current
+	r.Value -= e.zero + e.tare
+	r.Tare = e.tare
+	if e.tare != 0 {
+		r.Mode = ModeNet
+	}
+	return r, true
+}
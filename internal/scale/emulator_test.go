@@ -0,0 +1,157 @@
+package scale
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adcondev/scale-daemon/internal/config"
+)
+
+func TestEncodeRoundTrips(t *testing.T) {
+	readings := []Reading{
+		{Value: 12.5, Decimals: 2, Unit: "kg", Stable: true, Mode: ModeGross},
+		{Value: -1.25, Decimals: 3, Unit: "kg", Stable: false, Mode: ModeGross},
+		{Value: 3.4, Decimals: 1, Unit: "lb", Stable: true, Mode: ModeNet, Tare: 0.5},
+	}
+	for _, brand := range []string{"rhino", "torrey", "toledo", "mt-sics"} {
+		d, _ := LookupDriver(brand)
+		enc, ok := d.(Encoder)
+		if !ok {
+			t.Fatalf("%s driver does not implement Encoder", brand)
+		}
+		for _, want := range readings {
+			frames, _ := NewFramer(d.Framing()).Feed(enc.Encode(want))
+			if len(frames) != 1 {
+				t.Fatalf("%s: Encode(%v) framed as %q", brand, want, frames)
+			}
+			got, err := d.Parse(frames[0])
+			if err != nil {
+				t.Fatalf("%s: Parse(%q) error: %v", brand, frames[0], err)
+			}
+			if got.String() != want.String() {
+				t.Errorf("%s: round trip %q -> %s, want %s", brand, frames[0], got, want)
+			}
+			if got.HasStability && got.Stable != want.Stable {
+				t.Errorf("%s: round trip %q lost stability %v", brand, frames[0], want.Stable)
+			}
+			if got.Unit != "" && got.Unit != want.Unit {
+				t.Errorf("%s: round trip %q unit %q, want %q", brand, frames[0], got.Unit, want.Unit)
+			}
+		}
+	}
+
+	over := Reading{Value: 9.99, Decimals: 2, Unit: "kg", OutOfRange: true}
+	if _, err := (sicsDriver{}).Parse([]byte(strings.TrimSpace(string(sicsDriver{}.Encode(over))))); err == nil {
+		t.Error("Expected an SICS overload answer to parse as a status error")
+	}
+	if r, _ := (toledoDriver{}).Parse(toledoDriver{}.Encode(over)); !r.OutOfRange {
+		t.Error("Expected the Toledo out of range flag to round trip")
+	}
+}
+
+func TestEmulatorAnswersOperations(t *testing.T) {
+	emu, err := NewEmulator("mt-sics", &Scenario{
+		Steps: []ScenarioStep{{Type: StepWeight, Weight: weight(2.5), DurationMs: 1000}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go emu.Run(ctx)
+
+	daemon, indicator := net.Pipe()
+	defer func() { _ = daemon.Close() }()
+	go func() { _ = emu.Serve(ctx, indicator) }()
+
+	ask := func(cmd string) string {
+		t.Helper()
+		_ = daemon.SetDeadline(time.Now().Add(time.Second))
+		if _, err := daemon.Write([]byte(cmd)); err != nil {
+			t.Fatalf("Write(%q) error: %v", cmd, err)
+		}
+		buf := make([]byte, 64)
+		n, err := daemon.Read(buf)
+		if err != nil {
+			t.Fatalf("No answer to %q: %v", cmd, err)
+		}
+		return strings.Join(strings.Fields(string(buf[:n])), " ")
+	}
+
+	time.Sleep(20 * time.Millisecond) // first simulator tick
+	for _, step := range []struct{ cmd, want string }{
+		{"SI\r\n", "S S 2.50 kg"},
+		{"T\r\n", "T S 2.5 kg"},
+		{"SI\r\n", "S S 0.00 kg"},
+		{"TAC\r\n", "TAC A"},
+		{"S", ""}, // a partial command waits for the rest
+		{"I\r\n", "S S 2.50 kg"},
+		{"I4\r\n", `I4 A "EMU-0001"`},
+		{"TA 1.5 kg\r\n", "TA A 1.5 kg"},
+		{"SI\r\n", "S S 1.00 kg"},
+		{"TA 1", ""}, // the tare value arrives in pieces
+		{".25 kg\r\n", "TA A 1.25 kg"},
+		{"TA 2 lb\r\n", "TA L"},
+		{"TA -1 kg\r\n", "TA L"},
+		{"TA kg\r\n", "TA L"},
+		{"SI\r\n", "S S 1.25 kg"},
+		{"TAC\r\n", "TAC A"},
+	} {
+		if step.want == "" {
+			_, _ = daemon.Write([]byte(step.cmd))
+			continue
+		}
+		if got := ask(step.cmd); got != step.want {
+			t.Errorf("%q answered %q, want %q", step.cmd, got, step.want)
+		}
+	}
+
+	if _, err := NewEmulator("báscula fantasma", DefaultScenario()); err == nil {
+		t.Error("Expected an unknown brand to be rejected")
+	}
+}
+
+func TestReaderReadsEmulatorOverTCP(t *testing.T) {
+	for _, brand := range []string{"Rhino BAR 8RS", "Toledo 8142"} {
+		emu, err := NewEmulator(brand, &Scenario{
+			IntervalMs: 20,
+			Steps:      []ScenarioStep{{Type: StepWeight, Weight: weight(7.25), DurationMs: 1000}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		go emu.Run(ctx)
+		go func() {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer func() { _ = conn.Close() }()
+			_ = emu.Serve(ctx, conn)
+		}()
+
+		cfg := config.New(config.Environment{DefaultPort: "tcp://" + ln.Addr().String()})
+		cfg.Update("", brand, false)
+		broadcast := make(chan Event, 10)
+		go NewReader(cfg, broadcast).Start(ctx)
+
+		select {
+		case ev := <-broadcast:
+			if ev.String() != "7.25" {
+				t.Errorf("%s: published %q, want 7.25", brand, ev)
+			}
+		case <-time.After(3 * time.Second):
+			t.Errorf("%s: timed out waiting for an emulated reading", brand)
+		}
+		cancel()
+		_ = ln.Close()
+	}
+}
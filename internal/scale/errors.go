@@ -0,0 +1,174 @@
+package scale
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+
+	"go.bug.st/serial"
+
+	"github.com/adcondev/scale-daemon/internal/config"
+)
+
+// Error codes for scale communication failures. They are broadcast to
+// clients as bare strings, as in v1.
+const (
+	// ErrEOF is the error code for end-of-file received.
+	ErrEOF = "ERR_EOF"
+	// ErrTimeout is the error code for read timeout.
+	ErrTimeout = "ERR_TIMEOUT"
+	// ErrRead is the error code for read error.
+	ErrRead = "ERR_READ"
+	// ErrConnection is the error code for connection failure.
+	ErrConnection = "ERR_SCALE_CONN"
+	// ErrParse is the error code for a response that carries no weight.
+	ErrParse = "ERR_PARSE"
+	// ErrOverload is the error code for a weight above the scale capacity.
+	ErrOverload = "ERR_OVERLOAD"
+	// ErrUnderload is the error code for a weight below the scale minimum.
+	ErrUnderload = "ERR_UNDERLOAD"
+	// ErrPortBusy is the error code for a port opened by another program.
+	ErrPortBusy = "ERR_PORT_BUSY"
+)
+
+// ErrorDescriptions maps error codes to human-readable descriptions
+var ErrorDescriptions = map[string]string{
+	ErrEOF:        "EOF recibido. Posible desconexión.",
+	ErrTimeout:    "Timeout de lectura.",
+	ErrRead:       "Error de lectura.",
+	ErrConnection: "No se pudo conectar al puerto serial.",
+	ErrParse:      "Respuesta de la báscula no reconocida.",
+	ErrOverload:   "Sobrecarga: el peso excede la capacidad de la báscula.",
+	ErrUnderload:  "Bajo carga: el peso está por debajo del mínimo de la báscula.",
+	ErrPortBusy:   "El puerto serial está en uso por otra aplicación.",
+}
+
+// errReadTimeout is returned when a read timeout elapses without data.
+// Serial ports report it as a read of 0 bytes and no error.
+var errReadTimeout = fmt.Errorf("el puerto no devolvió datos: %w", os.ErrDeadlineExceeded)
+
+// ScaleError is a failure of the scale reader. Code is the ERR_* string
+// broadcast to clients.
+type ScaleError struct {
+	Code string
+	// Port is the puerto value in use when the error occurred.
+	Port string
+	// Cause is the underlying port or driver error, if any.
+	Cause error
+	// Count is how many times Code has occurred on the reader, this one
+	// included.
+	Count uint64
+}
+
+func (e *ScaleError) Error() string {
+	msg := e.Code + ": " + ErrorDescriptions[e.Code]
+	if e.Port != "" {
+		msg = e.Port + ": " + msg
+	}
+	if e.Cause != nil {
+		msg += " (" + e.Cause.Error() + ")"
+	}
+	return msg
+}
+
+func (e *ScaleError) Unwrap() error {
+	return e.Cause
+}
+
+// Is matches a ScaleError with the same Code, so callers can test for
+// &ScaleError{Code: ErrTimeout}
+func (e *ScaleError) Is(target error) bool {
+	t, ok := target.(*ScaleError)
+	return ok && t.Code == e.Code
+}
+
+// ErrorCode classifies a port or driver error into its broadcast code
+func ErrorCode(err error) string {
+	var scaleErr *ScaleError
+	var statusErr *StatusError
+	switch {
+	case errors.As(err, &scaleErr):
+		return scaleErr.Code
+	case errors.As(err, &statusErr):
+		return statusErr.Code
+	case errors.Is(err, io.EOF):
+		return ErrEOF
+	case isTimeout(err):
+		return ErrTimeout
+	case errors.Is(err, ErrUnparsable):
+		return ErrParse
+	case isPortBusy(err):
+		return ErrPortBusy
+	}
+	return ErrRead
+}
+
+// RangeCode returns ERR_OVERLOAD or ERR_UNDERLOAD for a reading the
+// indicator flags as out of range, or whose gross weight is outside rng.
+// It returns "" for readings within range.
+func RangeCode(rng config.RangeSettings, r Reading) string {
+	gross := r.Value
+	if r.Mode == ModeNet {
+		gross += r.Tare
+	}
+	switch {
+	case r.OutOfRange && r.Negative(), rng.Minimum < 0 && gross < rng.Minimum-1e-9:
+		return ErrUnderload
+	case r.OutOfRange, rng.Capacity > 0 && gross > rng.Capacity+1e-9:
+		return ErrOverload
+	}
+	return ""
+}
+
+// isTimeout reports a read timeout from a serial port or a socket
+func isTimeout(err error) bool {
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// isPortBusy reports a port that is open in another program
+func isPortBusy(err error) bool {
+	var portErr *serial.PortError
+	return errors.As(err, &portErr) && portErr.Code() == serial.PortBusy
+}
+
+// fail counts an occurrence of code and broadcasts it to clients
+func (r *Reader) fail(puerto, code string, cause error) *ScaleError {
+	err := &ScaleError{Code: code, Port: puerto, Cause: cause}
+
+	r.statusMu.Lock()
+	if r.errorCounts == nil {
+		r.errorCounts = make(map[string]uint64)
+	}
+	r.errorCounts[code]++
+	err.Count = r.errorCounts[code]
+	r.lastErr = err
+	r.notify(Event{Code: code})
+	r.statusMu.Unlock()
+
+	r.sendError(code)
+	return err
+}
+
+// ErrorCounts returns how many times each error code has occurred
+func (r *Reader) ErrorCounts() map[string]uint64 {
+	r.statusMu.Lock()
+	defer r.statusMu.Unlock()
+	counts := make(map[string]uint64, len(r.errorCounts))
+	for code, n := range r.errorCounts {
+		counts[code] = n
+	}
+	return counts
+}
+
+// LastError returns the most recent failure, or nil
+func (r *Reader) LastError() *ScaleError {
+	r.statusMu.Lock()
+	defer r.statusMu.Unlock()
+	return r.lastErr
+}
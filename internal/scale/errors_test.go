@@ -0,0 +1,139 @@
+package scale
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"go.bug.st/serial"
+
+	"github.com/adcondev/scale-daemon/internal/config"
+)
+
+func TestErrorCode(t *testing.T) {
+	a, b := net.Pipe()
+	defer func() { _ = a.Close(); _ = b.Close() }()
+	_ = a.SetReadDeadline(time.Now())
+	_, netTimeout := a.Read(make([]byte, 1))
+
+	for _, tt := range []struct {
+		err  error
+		code string
+	}{
+		{io.EOF, ErrEOF},
+		{fmt.Errorf("leyendo: %w", io.EOF), ErrEOF},
+		{errReadTimeout, ErrTimeout},
+		{netTimeout, ErrTimeout},
+		{&StatusError{Code: ErrOverload, Detail: "SICS SI: sobrecarga"}, ErrOverload},
+		{fmt.Errorf("%w: trama", ErrUnparsable), ErrParse},
+		{&ScaleError{Code: ErrPortBusy}, ErrPortBusy},
+		{errors.New("i/o error"), ErrRead},
+	} {
+		if got := ErrorCode(tt.err); got != tt.code {
+			t.Errorf("ErrorCode(%v) = %s, want %s", tt.err, got, tt.code)
+		}
+	}
+
+	err := fmt.Errorf("ciclo: %w", &ScaleError{Code: ErrTimeout, Port: "COM3", Cause: errReadTimeout, Count: 2})
+	if !errors.Is(err, &ScaleError{Code: ErrTimeout}) || errors.Is(err, &ScaleError{Code: ErrEOF}) {
+		t.Error("errors.Is() should match ScaleError by code")
+	}
+	if !errors.Is(err, errReadTimeout) {
+		t.Error("ScaleError should unwrap to its cause")
+	}
+}
+
+func TestReaderReportsTypedErrors(t *testing.T) {
+	origSerialOpen := serialOpen
+	defer func() { serialOpen = origSerialOpen }()
+
+	for _, tt := range []struct {
+		name  string
+		reply string
+		code  string
+	}{
+		{"silent indicator", "", ErrTimeout},
+		{"overload", "S +\r\n", ErrOverload},
+		{"underload", "S -\r\n", ErrUnderload},
+		{"garbage", "hola\r\n", ErrParse},
+	} {
+		serialOpen = func(_ string, _ *serial.Mode) (Port, error) {
+			return &scriptedPort{replies: map[string]string{"SI\r\n": tt.reply}}, nil
+		}
+		cfg := config.New(config.Environment{DefaultPort: "COM_TEST"})
+		cfg.Update("", "MT-SICS", false)
+		broadcast := make(chan Event, 10)
+		r := NewReader(cfg, broadcast)
+		ctx, cancel := context.WithCancel(context.Background())
+		go r.Start(ctx)
+
+		select {
+		case ev := <-broadcast:
+			if ev.Code != tt.code {
+				t.Errorf("%s: broadcast %q, want %s", tt.name, ev, tt.code)
+			}
+		case <-time.After(2 * time.Second):
+			t.Errorf("%s: timed out waiting for %s", tt.name, tt.code)
+		}
+		cancel()
+
+		last := r.LastError()
+		if last == nil || last.Code != tt.code || last.Port != "COM_TEST" || last.Count == 0 {
+			t.Errorf("%s: LastError() = %+v", tt.name, last)
+		}
+		if r.ErrorCounts()[tt.code] == 0 {
+			t.Errorf("%s: ErrorCounts() = %v", tt.name, r.ErrorCounts())
+		}
+	}
+}
+
+func TestRangeCode(t *testing.T) {
+	rng := config.RangeSettings{Capacity: 30, Minimum: -0.1}
+	for _, tt := range []struct {
+		reading Reading
+		code    string
+	}{
+		{Reading{Value: 12.5}, ""},
+		{Reading{Value: 30}, ""},
+		{Reading{Value: -0.05}, ""},
+		{Reading{Value: 30.01}, ErrOverload},
+		{Reading{Value: -0.2}, ErrUnderload},
+		{Reading{Value: 25, Mode: ModeNet, Tare: 10}, ErrOverload},
+		{Reading{Value: 1, OutOfRange: true}, ErrOverload},
+		{Reading{Value: -1, OutOfRange: true}, ErrUnderload},
+	} {
+		if got := RangeCode(rng, tt.reading); got != tt.code {
+			t.Errorf("RangeCode(%+v) = %q, want %q", tt.reading, got, tt.code)
+		}
+	}
+
+	if got := RangeCode(config.RangeSettings{}, Reading{Value: -500}); got != "" {
+		t.Errorf("A zero range should only honor driver flags, got %s", got)
+	}
+}
+
+func TestReaderReportsOutOfRange(t *testing.T) {
+	ch := make(chan Event, 10)
+	r := &Reader{broadcast: ch}
+	conf := config.Snapshot{Rango: config.RangeSettings{Capacity: 5, Minimum: -0.5}}
+
+	for _, frame := range []string{"9.00", "-1.00", "-0.20"} {
+		r.handleFrame(conf, rhinoDriver{}, []byte(frame))
+	}
+
+	var got []string
+	for len(ch) > 0 {
+		got = append(got, (<-ch).String())
+	}
+	want := []string{ErrOverload, ErrUnderload, "-0.20"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Published %v, want %v", got, want)
+	}
+	if counts := r.ErrorCounts(); counts[ErrOverload] != 1 || counts[ErrUnderload] != 1 {
+		t.Errorf("ErrorCounts() = %v", counts)
+	}
+}
@@ -0,0 +1,192 @@
+package scale
+
+import (
+	"bytes"
+)
+
+// Control characters used by scale protocols
+const (
+	stx = 0x02
+	etx = 0x03
+	cr  = 0x0D
+)
+
+// defaultMaxFrameLength bounds buffered bytes when no frame completes
+const defaultMaxFrameLength = 256
+
+// Framing describes how a driver's responses are delimited on the wire
+type Framing struct {
+	// Start marks the beginning of a frame (e.g. STX). Bytes before it are
+	// discarded as noise. The marker is kept as the first byte of the frame.
+	Start []byte
+	// Terminator marks the end of a frame (CR, CRLF, ETX). It is stripped.
+	Terminator []byte
+	// Length is the fixed frame length, including Start, used when the
+	// protocol has no terminator.
+	Length int
+	// Checksum computes the check byte that follows the terminator over
+	// the whole frame, delimiters included. Nil disables verification.
+	Checksum func(frame []byte) byte
+	// OptionalChecksum accepts frames whose terminator is directly followed
+	// by the next Start marker, for indicators where the checksum can be
+	// disabled in setup.
+	OptionalChecksum bool
+	// MaxLength is the longest frame accepted before the buffer is
+	// discarded as noise. Zero means defaultMaxFrameLength.
+	MaxLength int
+	// FlushOnIdle completes a frame from whatever was buffered once the
+	// line goes idle, for indicators that do not always send a terminator.
+	FlushOnIdle bool
+}
+
+// SumChecksum7 is the Mettler Toledo checksum: the two's complement of the
+// seven low-order bits of the sum of every byte in the frame.
+func SumChecksum7(frame []byte) byte {
+	var sum byte
+	for _, b := range frame {
+		sum += b
+	}
+	return -sum & 0x7F
+}
+
+// XORChecksum is the block check character used by STX/ETX protocols: the
+// XOR of every byte after the start marker up to and including the terminator.
+func XORChecksum(frame []byte) byte {
+	var bcc byte
+	for _, b := range frame[1:] {
+		bcc ^= b
+	}
+	return bcc
+}
+
+// Framer reassembles frames from the chunks returned by successive port
+// reads. It is not safe for concurrent use.
+type Framer struct {
+	framing Framing
+	buf     []byte
+}
+
+// NewFramer creates a framer for the given framing rules
+func NewFramer(f Framing) *Framer {
+	if f.MaxLength == 0 {
+		f.MaxLength = defaultMaxFrameLength
+	}
+	return &Framer{framing: f}
+}
+
+// Feed appends data read from the port and returns every complete frame
+// now available, oldest first, with the terminator and checksum removed.
+// garbled counts frames discarded for a bad checksum, a missing
+// terminator within MaxLength or a truncated start.
+func (fr *Framer) Feed(data []byte) (frames [][]byte, garbled int) {
+	fr.buf = append(fr.buf, data...)
+	f := fr.framing
+
+	for len(fr.buf) > 0 {
+		// Sync on the start marker, dropping noise before it
+		if len(f.Start) > 0 {
+			i := bytes.Index(fr.buf, f.Start)
+			if i < 0 {
+				keep := len(f.Start) - 1
+				if len(fr.buf) > keep {
+					fr.buf = fr.buf[len(fr.buf)-keep:]
+				}
+				break
+			}
+			fr.buf = fr.buf[i:]
+		}
+
+		var body []byte
+		var end int
+		switch {
+		case len(f.Terminator) > 0:
+			j := bytes.Index(fr.buf[len(f.Start):], f.Terminator)
+			if j < 0 {
+				if len(fr.buf) > f.MaxLength {
+					garbled++
+					fr.buf = fr.buf[len(fr.buf):]
+				}
+				return frames, garbled
+			}
+			j += len(f.Start)
+			body = fr.buf[:j]
+			end = j + len(f.Terminator)
+
+			// A second start marker means the first frame lost its tail
+			if len(f.Start) > 0 {
+				if k := bytes.LastIndex(body, f.Start); k > 0 {
+					garbled++
+					fr.buf = fr.buf[k:]
+					continue
+				}
+			}
+		case f.Length > 0:
+			if len(fr.buf) < f.Length {
+				return frames, garbled
+			}
+			body = fr.buf[:f.Length]
+			end = f.Length
+		default:
+			// No delimiters: every chunk is a frame
+			frames = append(frames, fr.take(len(fr.buf)))
+			return frames, garbled
+		}
+
+		if f.Checksum != nil {
+			if end >= len(fr.buf) {
+				// Wait for the check byte (or the next start marker)
+				return frames, garbled
+			}
+			check := fr.buf[end]
+			valid := f.Checksum(fr.buf[:end]) == check
+			switch {
+			case f.OptionalChecksum && len(f.Start) > 0 && check == f.Start[0]:
+				// Either a checksum that happens to equal the start marker or,
+				// with checksums off, the next frame. It is the checksum when
+				// it validates and is not followed by frame content.
+				if next := end + 1; valid && (next >= len(fr.buf) || fr.buf[next] == f.Start[0]) {
+					end++
+				}
+			case !valid:
+				garbled++
+				fr.buf = fr.buf[end+1:]
+				continue
+			default:
+				end++
+			}
+		}
+
+		frame := append([]byte(nil), body...)
+		fr.buf = fr.buf[end:]
+		if len(bytes.TrimSpace(frame)) > 0 {
+			frames = append(frames, frame)
+		}
+	}
+
+	return frames, garbled
+}
+
+// Flush returns the buffered bytes as a frame when the framing allows
+// frames to be completed by an idle line; otherwise it returns nil.
+func (fr *Framer) Flush() []byte {
+	if !fr.framing.FlushOnIdle || len(bytes.TrimSpace(fr.buf)) == 0 {
+		return nil
+	}
+	return fr.take(len(fr.buf))
+}
+
+// Pending returns the number of buffered bytes not yet part of a frame
+func (fr *Framer) Pending() int {
+	return len(fr.buf)
+}
+
+// Reset discards any buffered bytes
+func (fr *Framer) Reset() {
+	fr.buf = fr.buf[:0]
+}
+
+func (fr *Framer) take(n int) []byte {
+	frame := append([]byte(nil), fr.buf[:n]...)
+	fr.buf = fr.buf[n:]
+	return frame
+}
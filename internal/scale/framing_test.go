@@ -0,0 +1,133 @@
+package scale
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFramerReassemblesFragments(t *testing.T) {
+	f := NewFramer(Framing{Terminator: []byte("\r\n")})
+
+	var got []string
+	for _, chunk := range []string{"S S   ", "  12.3", "45 g\r", "\nS D  1", ".000 g\r\n"} {
+		frames, garbled := f.Feed([]byte(chunk))
+		if garbled != 0 {
+			t.Errorf("Feed(%q) garbled = %d", chunk, garbled)
+		}
+		for _, fr := range frames {
+			got = append(got, string(fr))
+		}
+	}
+
+	want := []string{"S S     12.345 g", "S D  1.000 g"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("frames = %q, want %q", got, want)
+	}
+	if f.Pending() != 0 {
+		t.Errorf("Pending() = %d, want 0", f.Pending())
+	}
+}
+
+func TestFramerDiscardsNoiseBeforeStart(t *testing.T) {
+	f := NewFramer(Framing{Start: []byte{stx}, Terminator: []byte{etx}})
+
+	frames, garbled := f.Feed([]byte("\xff\x00noise\x0212.50\x03"))
+	if garbled != 0 || len(frames) != 1 || string(frames[0]) != "\x0212.50" {
+		t.Errorf("Feed() = %q, garbled %d", frames, garbled)
+	}
+
+	// A frame that lost its tail is dropped when the next one starts
+	frames, garbled = f.Feed([]byte("\x0212.\x0213.00\x03"))
+	if garbled != 1 || len(frames) != 1 || string(frames[0]) != "\x0213.00" {
+		t.Errorf("Feed() = %q, garbled %d", frames, garbled)
+	}
+}
+
+func TestFramerXORChecksum(t *testing.T) {
+	f := NewFramer(Framing{Start: []byte{stx}, Terminator: []byte{etx}, Checksum: XORChecksum})
+
+	raw := []byte("\x0212.50\x03")
+	good := append(append([]byte(nil), raw...), XORChecksum(raw))
+	bad := append(append([]byte(nil), raw...), XORChecksum(raw)^0xFF)
+
+	frames, garbled := f.Feed(append(bad, good...))
+	if garbled != 1 || len(frames) != 1 || string(frames[0]) != "\x0212.50" {
+		t.Errorf("Feed() = %q, garbled %d", frames, garbled)
+	}
+}
+
+func TestFramerOptionalChecksumEqualToStart(t *testing.T) {
+	f := NewFramer(Framing{
+		Start:            []byte{stx},
+		Terminator:       []byte{cr},
+		Checksum:         SumChecksum7,
+		OptionalChecksum: true,
+	})
+
+	// The sum of "\x020123)\r" makes its checksum 0x02, the start marker
+	frame := "\x020123)\r"
+	if SumChecksum7([]byte(frame)) != stx {
+		t.Fatalf("Test frame checksum = %#x, want STX", SumChecksum7([]byte(frame)))
+	}
+	stream := strings.Repeat(frame+"\x02", 3)
+	var got []string
+	for i := 0; i < len(stream); i++ {
+		frames, garbled := f.Feed([]byte{stream[i]})
+		if garbled != 0 {
+			t.Fatalf("Feed() garbled = %d at byte %d", garbled, i)
+		}
+		for _, fr := range frames {
+			got = append(got, string(fr))
+		}
+	}
+	if len(got) != 3 || got[0] != "\x020123)" || f.Pending() != 0 {
+		t.Errorf("Frames = %q, pending %d", got, f.Pending())
+	}
+
+	// Without checksums the same frames follow each other directly
+	f.Reset()
+	frames, garbled := f.Feed([]byte(strings.Repeat(frame, 3)))
+	if garbled != 0 || len(frames) != 2 {
+		t.Errorf("Feed() without checksums = %q, garbled %d", frames, garbled)
+	}
+}
+
+func TestFramerFixedLength(t *testing.T) {
+	f := NewFramer(Framing{Start: []byte{stx}, Length: 4})
+
+	frames, _ := f.Feed([]byte("x\x02abc\x02de"))
+	if len(frames) != 1 || string(frames[0]) != "\x02abc" {
+		t.Errorf("Feed() = %q", frames)
+	}
+	frames, _ = f.Feed([]byte("f"))
+	if len(frames) != 1 || string(frames[0]) != "\x02def" {
+		t.Errorf("Feed() = %q", frames)
+	}
+}
+
+func TestFramerMaxLength(t *testing.T) {
+	f := NewFramer(Framing{Terminator: []byte{cr}, MaxLength: 8})
+
+	frames, garbled := f.Feed([]byte("0123456789"))
+	if garbled != 1 || len(frames) != 0 || f.Pending() != 0 {
+		t.Errorf("Feed() = %q, garbled %d, pending %d", frames, garbled, f.Pending())
+	}
+}
+
+func TestFramerFlushOnIdle(t *testing.T) {
+	f := NewFramer(rhinoDriver{}.Framing())
+
+	frames, _ := f.Feed([]byte("10.50"))
+	if len(frames) != 0 {
+		t.Fatalf("Feed() = %q, want no frames before terminator", frames)
+	}
+	if got := string(f.Flush()); got != "10.50" {
+		t.Errorf("Flush() = %q, want %q", got, "10.50")
+	}
+
+	strict := NewFramer(sicsDriver{}.Framing())
+	strict.Feed([]byte("S S 1.0 g"))
+	if got := strict.Flush(); got != nil {
+		t.Errorf("Flush() on strict framing = %q, want nil", got)
+	}
+}
@@ -0,0 +1,277 @@
+package scale
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	RegisterDriver(sicsDriver{}, "mt-sics", "sics", "mt sics", "mettler toledo sics")
+}
+
+// sicsDriver speaks the Mettler Toledo Standard Interface Command Set.
+// Weights are polled with SI, which answers immediately with the current
+// value and a stability status:
+//
+//	SI -> S S      12.345 g   (stable)
+//	SI -> S D      12.346 g   (dynamic)
+//	SI -> S I | S + | S -     (busy, overload, underload)
+//
+// Tare operations use T (tare), TAC (clear tare) and TA (preset tare).
+type sicsDriver struct{}
+
+func (sicsDriver) Name() string { return "MT-SICS" }
+
+func (sicsDriver) Command() []byte { return []byte("SI\r\n") }
+
+func (sicsDriver) Framing() Framing { return Framing{Terminator: []byte("\r\n")} }
+
+func (sicsDriver) Parse(frame []byte) (Reading, error) {
+	r := Reading{Raw: append([]byte(nil), frame...), Mode: ModeNet, HasStability: true}
+
+	fields, err := sicsFields(frame, "S")
+	if err != nil {
+		return r, err
+	}
+
+	switch fields[1] {
+	case "S":
+		r.Stable = true
+	case "D":
+		r.Stable = false
+	default:
+		return r, sicsStatusError("S", fields[1])
+	}
+
+	if len(fields) < 3 {
+		return r, fmt.Errorf("%w: respuesta SICS sin peso %q", ErrUnparsable, frame)
+	}
+	value, decimals, _, err := scanNumber(fields[2])
+	if err != nil {
+		return r, err
+	}
+	r.Value = value
+	r.Decimals = decimals
+	if len(fields) > 3 {
+		r.Unit = scanUnit(fields[3])
+	}
+	return r, nil
+}
+
+// Encode answers SI. Out of range readings answer S + or S - instead of
+// a weight.
+func (sicsDriver) Encode(r Reading) []byte {
+	switch {
+	case r.OutOfRange && r.Negative():
+		return []byte("S -\r\n")
+	case r.OutOfRange:
+		return []byte("S +\r\n")
+	}
+	status := "S"
+	if !r.Stable {
+		status = "D"
+	}
+	return fmt.Appendf(nil, "S %s %10s %s\r\n", status, r.String(), sicsUnit(r.Unit))
+}
+
+// EncodeOperation answers T, TAC, TA, Z and I4
+func (sicsDriver) EncodeOperation(res OperationResult) []byte {
+	switch res.Op {
+	case OpTare:
+		return fmt.Appendf(nil, "T S %10s %s\r\n", strconv.FormatFloat(res.Value, 'f', -1, 64), sicsUnit(res.Unit))
+	case OpPresetTare:
+		return fmt.Appendf(nil, "TA A %10s %s\r\n", strconv.FormatFloat(res.Value, 'f', -1, 64), sicsUnit(res.Unit))
+	case OpClearTare:
+		return []byte("TAC A\r\n")
+	case OpZero:
+		return []byte("Z A\r\n")
+	case OpSerialNumber:
+		return fmt.Appendf(nil, "I4 A %s\r\n", strconv.Quote(res.Text))
+	}
+	return []byte("ES\r\n")
+}
+
+// OperationPrefix returns the start of TA, the only command with
+// parameters
+func (sicsDriver) OperationPrefix(op Operation) []byte {
+	if op == OpPresetTare {
+		return []byte("TA ")
+	}
+	return nil
+}
+
+// DecodeOperation reads the tare of "TA 100.00 g". The value must not be
+// negative.
+func (sicsDriver) DecodeOperation(op Operation, line []byte) (OperationParams, error) {
+	fields := strings.Fields(string(line))
+	if op != OpPresetTare || len(fields) != 3 || fields[0] != "TA" {
+		return OperationParams{}, fmt.Errorf("%w: %q", ErrUnparsable, line)
+	}
+	value, _, rest, err := scanNumber(fields[1])
+	if err != nil || rest != "" || fields[1][0] < '0' || fields[1][0] > '9' {
+		return OperationParams{}, fmt.Errorf("%w: tara %q", ErrUnparsable, fields[1])
+	}
+	unit, err := ParseUnit(fields[2])
+	if err != nil {
+		return OperationParams{}, err
+	}
+	return OperationParams{Value: value, Unit: unit}, nil
+}
+
+// EncodeOperationError answers L, a parameter out of range, to TA and ES
+// to anything else
+func (sicsDriver) EncodeOperationError(op Operation) []byte {
+	if op == OpPresetTare {
+		return []byte("TA L\r\n")
+	}
+	return []byte("ES\r\n")
+}
+
+func sicsUnit(unit string) string {
+	if unit == "" {
+		return UnitKilogram
+	}
+	return unit
+}
+
+func (sicsDriver) OperationCommand(op Operation, params OperationParams) ([]byte, error) {
+	switch op {
+	case OpTare:
+		return []byte("T\r\n"), nil
+	case OpClearTare:
+		return []byte("TAC\r\n"), nil
+	case OpPresetTare:
+		if params.Unit == "" {
+			return nil, errors.New("SICS TA requiere la unidad de la tara")
+		}
+		return []byte(fmt.Sprintf("TA %s %s\r\n", strconv.FormatFloat(params.Value, 'f', -1, 64), params.Unit)), nil
+	case OpZero:
+		return []byte("Z\r\n"), nil
+	case OpSerialNumber:
+		return []byte("I4\r\n"), nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedOperation, op)
+}
+
+func (sicsDriver) ParseOperation(op Operation, frame []byte) (OperationResult, error) {
+	res := OperationResult{Op: op}
+
+	switch op {
+	case OpTare:
+		// T S    100.00 g
+		fields, err := sicsFields(frame, "T")
+		if err != nil {
+			return res, err
+		}
+		if fields[1] != "S" {
+			return res, sicsStatusError("T", fields[1])
+		}
+		if len(fields) > 2 {
+			if res.Value, _, _, err = scanNumber(fields[2]); err != nil {
+				return res, err
+			}
+		}
+		if len(fields) > 3 {
+			res.Unit = scanUnit(fields[3])
+		}
+
+	case OpClearTare:
+		// TAC A
+		fields, err := sicsFields(frame, "TAC")
+		if err != nil {
+			return res, err
+		}
+		if fields[1] != "A" {
+			return res, sicsStatusError("TAC", fields[1])
+		}
+
+	case OpPresetTare:
+		// TA A    100.00 g
+		fields, err := sicsFields(frame, "TA")
+		if err != nil {
+			return res, err
+		}
+		if fields[1] != "A" {
+			return res, sicsStatusError("TA", fields[1])
+		}
+		if len(fields) > 2 {
+			if res.Value, _, _, err = scanNumber(fields[2]); err != nil {
+				return res, err
+			}
+		}
+		if len(fields) > 3 {
+			res.Unit = scanUnit(fields[3])
+		}
+
+	case OpZero:
+		// Z A
+		fields, err := sicsFields(frame, "Z")
+		if err != nil {
+			return res, err
+		}
+		if fields[1] != "A" {
+			return res, sicsStatusError("Z", fields[1])
+		}
+
+	case OpSerialNumber:
+		// I4 A "0123456789"
+		fields, err := sicsFields(frame, "I4")
+		if err != nil {
+			return res, err
+		}
+		if fields[1] != "A" {
+			return res, sicsStatusError("I4", fields[1])
+		}
+		text := strings.TrimSpace(string(frame))
+		if i := strings.IndexByte(text, '"'); i >= 0 {
+			if s, err := strconv.Unquote(text[i:]); err == nil {
+				res.Text = s
+			} else {
+				res.Text = strings.Trim(text[i:], `"`)
+			}
+		}
+
+	default:
+		return res, fmt.Errorf("%w: %s", ErrUnsupportedOperation, op)
+	}
+
+	return res, nil
+}
+
+// sicsFields splits a reply and checks it answers the expected command.
+// Generic error replies (ES, ET, EL) are converted to status errors.
+func sicsFields(frame []byte, cmd string) ([]string, error) {
+	fields := strings.Fields(string(frame))
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("%w: respuesta SICS vacía", ErrUnparsable)
+	}
+	switch fields[0] {
+	case "ES":
+		return nil, &StatusError{Code: ErrRead, Detail: "SICS: error de sintaxis"}
+	case "ET":
+		return nil, &StatusError{Code: ErrRead, Detail: "SICS: error de transmisión"}
+	case "EL":
+		return nil, &StatusError{Code: ErrRead, Detail: "SICS: error lógico"}
+	}
+	if fields[0] != cmd || len(fields) < 2 {
+		return nil, fmt.Errorf("%w: respuesta SICS inesperada %q", ErrUnparsable, frame)
+	}
+	return fields, nil
+}
+
+// sicsStatusError maps an MT-SICS status code to a broadcast error code
+func sicsStatusError(cmd, status string) error {
+	switch status {
+	case "I":
+		return &StatusError{Code: ErrRead, Detail: fmt.Sprintf("SICS %s: comando no ejecutable (báscula ocupada o en movimiento)", cmd)}
+	case "+":
+		return &StatusError{Code: ErrOverload, Detail: fmt.Sprintf("SICS %s: sobrecarga", cmd)}
+	case "-":
+		return &StatusError{Code: ErrUnderload, Detail: fmt.Sprintf("SICS %s: bajo cero", cmd)}
+	case "L":
+		return &StatusError{Code: ErrRead, Detail: fmt.Sprintf("SICS %s: parámetro fuera de rango", cmd)}
+	}
+	return fmt.Errorf("%w: estado SICS %s %q", ErrUnparsable, cmd, status)
+}
@@ -0,0 +1,189 @@
+package scale
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go.bug.st/serial"
+
+	"github.com/adcondev/scale-daemon/internal/config"
+)
+
+// scriptedPort answers each written command with a canned reply
+type scriptedPort struct {
+	mu      sync.Mutex
+	replies map[string]string
+	pending []byte
+	writes  []string
+}
+
+func (p *scriptedPort) Read(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n := copy(b, p.pending)
+	p.pending = p.pending[n:]
+	return n, nil
+}
+
+func (p *scriptedPort) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.writes = append(p.writes, string(b))
+	p.pending = append(p.pending, p.replies[string(b)]...)
+	return len(b), nil
+}
+
+func (p *scriptedPort) Close() error { return nil }
+
+func (p *scriptedPort) SetReadTimeout(_ time.Duration) error { return nil }
+
+func TestSICSParse(t *testing.T) {
+	d := sicsDriver{}
+
+	tests := []struct {
+		in     string
+		value  float64
+		wire   string
+		unit   string
+		stable bool
+	}{
+		{"S S      12.345 g", 12.345, "12.345", "g", true},
+		{"S D       0.500 kg", 0.5, "0.500", "kg", false},
+		{"S S     -0.012 g", -0.012, "-0.012", "g", true},
+	}
+
+	for _, tt := range tests {
+		r, err := d.Parse([]byte(tt.in))
+		if err != nil {
+			t.Errorf("Parse(%q) returned error: %v", tt.in, err)
+			continue
+		}
+		if r.Value != tt.value || r.Unit != tt.unit || r.Stable != tt.stable || r.String() != tt.wire {
+			t.Errorf("Parse(%q) = %+v", tt.in, r)
+		}
+	}
+}
+
+func TestSICSStatusCodes(t *testing.T) {
+	d := sicsDriver{}
+
+	for in, code := range map[string]string{
+		"S I": ErrRead, "S +": ErrOverload, "S -": ErrUnderload, "ES": ErrRead, "ET": ErrRead, "EL": ErrRead,
+	} {
+		_, err := d.Parse([]byte(in))
+		var statusErr *StatusError
+		if !errors.As(err, &statusErr) {
+			t.Errorf("Parse(%q) error = %v, want StatusError", in, err)
+			continue
+		}
+		if statusErr.Code != code {
+			t.Errorf("Parse(%q) code = %s, want %s", in, statusErr.Code, code)
+		}
+	}
+
+	for _, in := range []string{"", "garbage", "T S 1.0 g", "S X 1.0 g"} {
+		if _, err := d.Parse([]byte(in)); !errors.Is(err, ErrUnparsable) {
+			t.Errorf("Parse(%q) error = %v, want ErrUnparsable", in, err)
+		}
+	}
+}
+
+func TestSICSOperations(t *testing.T) {
+	d := sicsDriver{}
+
+	res, err := d.ParseOperation(OpTare, []byte("T S    100.00 g"))
+	if err != nil || res.Value != 100 || res.Unit != "g" {
+		t.Errorf("tare reply = %+v, %v", res, err)
+	}
+	if _, err := d.ParseOperation(OpTare, []byte("T I")); err == nil {
+		t.Error("Expected error for tare while busy")
+	}
+
+	cmd, err := d.OperationCommand(OpPresetTare, OperationParams{Value: 1.5, Unit: "kg"})
+	if err != nil || string(cmd) != "TA 1.5 kg\r\n" {
+		t.Errorf("preset tare command = %q, %v", cmd, err)
+	}
+	res, err = d.ParseOperation(OpPresetTare, []byte("TA A      1.500 kg"))
+	if err != nil || res.Value != 1.5 || res.Unit != "kg" {
+		t.Errorf("preset tare reply = %+v, %v", res, err)
+	}
+	if _, err := d.ParseOperation(OpClearTare, []byte("TAC A")); err != nil {
+		t.Errorf("clear tare reply error: %v", err)
+	}
+
+	if _, err := d.ParseOperation(OpZero, []byte("Z A")); err != nil {
+		t.Errorf("zero reply error: %v", err)
+	}
+	if _, err := d.ParseOperation(OpZero, []byte("Z +")); err == nil {
+		t.Error("Expected error for zero on overload")
+	}
+
+	res, err = d.ParseOperation(OpSerialNumber, []byte(`I4 A "0123456789"`))
+	if err != nil || res.Text != "0123456789" {
+		t.Errorf("serial number reply = %+v, %v", res, err)
+	}
+
+	if _, err := d.OperationCommand("unknown", OperationParams{}); !errors.Is(err, ErrUnsupportedOperation) {
+		t.Errorf("OperationCommand(unknown) error = %v", err)
+	}
+}
+
+func TestReaderExecuteSICS(t *testing.T) {
+	cfg := config.New(config.Environment{DefaultPort: "COM_TEST"})
+	cfg.Update("", "MT-SICS", false)
+
+	port := &scriptedPort{replies: map[string]string{
+		"SI\r\n":        "S S      1.000 kg\r\n",
+		"T\r\n":         "T S      1.000 kg\r\n",
+		"TA 0.5 kg\r\n": "TA A      0.500 kg\r\n",
+		"I4\r\n":        "I4 A \"SN42\"\r\n",
+	}}
+
+	origSerialOpen := serialOpen
+	defer func() { serialOpen = origSerialOpen }()
+	serialOpen = func(_ string, _ *serial.Mode) (Port, error) {
+		return port, nil
+	}
+
+	r := NewReader(cfg, make(chan Event, 10))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.Start(ctx)
+
+	// Wait for the first reading, which always comes as net weight
+	deadline := time.Now().Add(time.Second)
+	for r.currentUnit() == "" && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	opCtx, opCancel := context.WithTimeout(ctx, 5*time.Second)
+	defer opCancel()
+
+	res, err := r.Execute(opCtx, OpTare, OperationParams{})
+	if err != nil || res.Value != 1 || res.Unit != "kg" {
+		t.Errorf("Execute(tare) = %+v, %v", res, err)
+	}
+	// TA needs a unit; it defaults to the unit of the last net reading
+	res, err = r.Execute(opCtx, OpPresetTare, OperationParams{Value: 0.5})
+	if err != nil || res.Software || res.Value != 0.5 || res.Unit != "kg" {
+		t.Errorf("Execute(presetTare) without unit = %+v, %v", res, err)
+	}
+	res, err = r.Execute(opCtx, OpSerialNumber, OperationParams{})
+	if err != nil || res.Text != "SN42" {
+		t.Errorf("Execute(serialNumber) = %+v, %v", res, err)
+	}
+}
+
+func TestReaderExecuteUnsupported(t *testing.T) {
+	cfg := config.New(config.Environment{DefaultPort: "COM_TEST"})
+	r := NewReader(cfg, make(chan Event, 1))
+
+	for _, op := range []Operation{OpZero, OpSerialNumber} {
+		if _, err := r.Execute(context.Background(), op, OperationParams{}); !errors.Is(err, ErrUnsupportedOperation) {
+			t.Errorf("Execute(%s) on Rhino driver error = %v, want ErrUnsupportedOperation", op, err)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package scale
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"go.bug.st/serial/enumerator"
+
+	"github.com/adcondev/scale-daemon/internal/config"
+)
+
+// probeReadTimeout keeps individual reads short within the probe window
+const probeReadTimeout = 100 * time.Millisecond
+
+// probeWindow is how long each driver and line setting is listened to
+var probeWindow = 800 * time.Millisecond
+
+// PortInfo describes a serial port available on the host
+type PortInfo struct {
+	Name string
+	// USB details, empty for built-in ports
+	USB          bool
+	VID          string
+	PID          string
+	SerialNumber string
+	Product      string
+}
+
+// variable to allow mocking port enumeration
+var listPorts = func() ([]PortInfo, error) {
+	details, err := enumerator.GetDetailedPortsList()
+	if err != nil {
+		return nil, err
+	}
+	ports := make([]PortInfo, 0, len(details))
+	for _, d := range details {
+		ports = append(ports, PortInfo{
+			Name:         d.Name,
+			USB:          d.IsUSB,
+			VID:          d.VID,
+			PID:          d.PID,
+			SerialNumber: d.SerialNumber,
+			Product:      d.Product,
+		})
+	}
+	return ports, nil
+}
+
+// ListPorts returns the serial ports present on the host, sorted by name
+func ListPorts() ([]PortInfo, error) {
+	ports, err := listPorts()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(ports, func(i, j int) bool { return ports[i].Name < ports[j].Name })
+	return ports, nil
+}
+
+// ProbeLineSettings are the line settings tried by Probe, most common
+// factory defaults first
+var ProbeLineSettings = []config.SerialSettings{
+	{BaudRate: 9600, DataBits: 8, Parity: "N", StopBits: 1},
+	{BaudRate: 9600, DataBits: 7, Parity: "E", StopBits: 1},
+	{BaudRate: 4800, DataBits: 7, Parity: "E", StopBits: 1},
+	{BaudRate: 4800, DataBits: 8, Parity: "N", StopBits: 1},
+	{BaudRate: 2400, DataBits: 7, Parity: "E", StopBits: 1},
+	{BaudRate: 19200, DataBits: 8, Parity: "N", StopBits: 1},
+}
+
+// ProbeResult is a port, driver and line setting that produced a valid weight
+type ProbeResult struct {
+	Port    string
+	Brand   string
+	Serial  config.SerialSettings
+	Reading Reading
+}
+
+// Probe opens each port with every candidate line setting and tries every
+// registered driver, returning the combinations that produced a valid
+// weight. Ports in use by a reader must not be passed in. Drivers whose
+// protocols overlap may all match the same port; the generic default
+// driver is tried last so more specific matches come first. Raw tcp://
+// ports are tried once, since their line settings belong to the converter.
+func Probe(ctx context.Context, ports []string, lines []config.SerialSettings) []ProbeResult {
+	drivers := probeDrivers()
+	var results []ProbeResult
+
+	for _, port := range ports {
+		portLines := lines
+		if !hasLineSettings(port) && len(lines) > 1 {
+			// Line settings belong to the converter, not the socket
+			portLines = lines[:1]
+		}
+		for _, line := range portLines {
+			line = line.Merge(config.DefaultSerialSettings())
+			for _, driver := range drivers {
+				if ctx.Err() != nil {
+					return results
+				}
+				reading, ok := probeOne(ctx, port, driver, line)
+				if !ok {
+					continue
+				}
+				log.Printf("[OK] Sondeo: %s responde como %s (%s): %s", port, driver.Name(), line, reading)
+				results = append(results, ProbeResult{Port: port, Brand: driver.Name(), Serial: line, Reading: reading})
+			}
+		}
+	}
+	return results
+}
+
+// probeDrivers returns one driver per brand with the default brand last
+func probeDrivers() []Driver {
+	var drivers []Driver
+	var fallback Driver
+	for _, brand := range Brands() {
+		d, err := LookupDriver(brand)
+		if err != nil {
+			continue
+		}
+		if strings.EqualFold(brand, DefaultBrand) {
+			fallback = d
+			continue
+		}
+		drivers = append(drivers, d)
+	}
+	if fallback != nil {
+		drivers = append(drivers, fallback)
+	}
+	return drivers
+}
+
+// probeOne listens to a port for probeWindow, polling first when the
+// driver has a command, and reports the first frame the driver parses.
+func probeOne(ctx context.Context, port string, driver Driver, line config.SerialSettings) (Reading, bool) {
+	p, err := openPort(port, line)
+	if err != nil {
+		return Reading{}, false
+	}
+	defer func() { _ = p.Close() }()
+	if err := p.SetReadTimeout(probeReadTimeout); err != nil {
+		return Reading{}, false
+	}
+
+	if cmd := driver.Command(); len(cmd) > 0 {
+		if _, err := p.Write(cmd); err != nil {
+			return Reading{}, false
+		}
+	}
+
+	framer := NewFramer(driver.Framing())
+	buf := make([]byte, readBufferSize)
+	deadline := time.Now().Add(probeWindow)
+	for time.Now().Before(deadline) && ctx.Err() == nil {
+		n, err := p.Read(buf)
+		if err != nil {
+			return Reading{}, false
+		}
+		frames, _ := framer.Feed(buf[:n])
+		if n == 0 {
+			if frame := framer.Flush(); frame != nil {
+				frames = append(frames, frame)
+			}
+		}
+		for _, frame := range frames {
+			if reading, err := driver.Parse(frame); err == nil {
+				return reading, true
+			}
+		}
+	}
+	return Reading{}, false
+}
@@ -0,0 +1,72 @@
+package scale
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.bug.st/serial"
+
+	"github.com/adcondev/scale-daemon/internal/config"
+)
+
+func TestProbeFindsDriverAndLineSettings(t *testing.T) {
+	origSerialOpen, origWindow := serialOpen, probeWindow
+	defer func() { serialOpen, probeWindow = origSerialOpen, origWindow }()
+	probeWindow = 20 * time.Millisecond
+
+	// An MT-SICS balance on COM5 configured for 4800 7E1; COM6 has nothing attached
+	serialOpen = func(name string, mode *serial.Mode) (Port, error) {
+		if name == "COM5" && mode.BaudRate == 4800 && mode.DataBits == 7 && mode.Parity == serial.EvenParity {
+			return &scriptedPort{replies: map[string]string{"SI\r\n": "S S      2.500 kg\r\n"}}, nil
+		}
+		if name == "COM5" || name == "COM6" {
+			return &scriptedPort{}, nil
+		}
+		return nil, errors.New("port not found")
+	}
+
+	results := Probe(context.Background(), []string{"COM5", "COM6", "COM7"}, ProbeLineSettings)
+	if len(results) != 1 {
+		t.Fatalf("Probe() = %+v, want a single match", results)
+	}
+	got := results[0]
+	if got.Port != "COM5" || got.Brand != "MT-SICS" || got.Serial.String() != "4800 7E1" || got.Reading.String() != "2.500" {
+		t.Errorf("Probe() match = %+v", got)
+	}
+	if got.Serial.ReadTimeout != config.DefaultReadTimeout {
+		t.Errorf("Probe() result should carry the default read timeout, got %s", got.Serial.ReadTimeout)
+	}
+}
+
+func TestReaderPauseKeepsPortClosed(t *testing.T) {
+	origSerialOpen := serialOpen
+	defer func() { serialOpen = origSerialOpen }()
+	serialOpen = func(_ string, _ *serial.Mode) (Port, error) {
+		return &scriptedPort{}, nil
+	}
+
+	r := NewReader(config.New(config.Environment{DefaultPort: "COM_TEST"}), make(chan Event, 10))
+	r.Pause()
+	if err := r.connect("COM_TEST", config.DefaultSerialSettings()); !errors.Is(err, errPaused) {
+		t.Errorf("connect() while paused error = %v, want errPaused", err)
+	}
+	r.Resume()
+	if err := r.connect("COM_TEST", config.DefaultSerialSettings()); err != nil {
+		t.Errorf("connect() after Resume error = %v", err)
+	}
+}
+
+func TestListPortsSorted(t *testing.T) {
+	origListPorts := listPorts
+	defer func() { listPorts = origListPorts }()
+	listPorts = func() ([]PortInfo, error) {
+		return []PortInfo{{Name: "COM4"}, {Name: "COM1", USB: true, VID: "0403", PID: "6001"}}, nil
+	}
+
+	ports, err := ListPorts()
+	if err != nil || len(ports) != 2 || ports[0].Name != "COM1" || ports[0].VID != "0403" {
+		t.Errorf("ListPorts() = %+v, %v", ports, err)
+	}
+}
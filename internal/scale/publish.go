@@ -0,0 +1,45 @@
+package scale
+
+import (
+	"math"
+	"time"
+
+	"github.com/adcondev/scale-daemon/internal/config"
+)
+
+// publisher applies the change-only publishing policy. It remembers the
+// last reading sent to clients and withholds readings that stay within the
+// deadband until the heartbeat is due.
+type publisher struct {
+	last  Reading
+	at    time.Time
+	valid bool
+}
+
+// publish reports whether reading must be sent to clients and, if so,
+// records it as the last one sent
+func (p *publisher) publish(policy config.PublishSettings, reading Reading, now time.Time) bool {
+	if policy.Heartbeat > 0 && p.valid && !p.changed(policy.Deadband, reading) &&
+		now.Sub(p.at) < policy.Heartbeat {
+		return false
+	}
+	p.last, p.at, p.valid = reading, now, true
+	return true
+}
+
+// changed reports a reading that moved beyond the deadband, or whose
+// stability, unit, mode or range flag differs from the last one sent
+func (p *publisher) changed(deadband float64, r Reading) bool {
+	last := p.last
+	if r.Stable != last.Stable || r.Unit != last.Unit || r.Mode != last.Mode ||
+		r.OutOfRange != last.OutOfRange || r.Decimals != last.Decimals {
+		return true
+	}
+	return math.Abs(r.Value-last.Value) > deadband*divisionFor(r.Division, r)+1e-9
+}
+
+// reset makes the next reading be sent, e.g. after an error code replaced
+// the weight on the clients' displays
+func (p *publisher) reset() {
+	*p = publisher{}
+}
@@ -0,0 +1,73 @@
+package scale
+
+import (
+	"testing"
+	"time"
+
+	"github.com/adcondev/scale-daemon/internal/config"
+)
+
+func TestPublisherHeartbeat(t *testing.T) {
+	policy := config.PublishSettings{Heartbeat: 2 * time.Second}
+	reading := Reading{Value: 0, Decimals: 2}
+	start := time.Now()
+
+	var p publisher
+	for _, tt := range []struct {
+		after time.Duration
+		want  bool
+	}{
+		{0, true},
+		{time.Second, false},
+		{1999 * time.Millisecond, false},
+		{2 * time.Second, true},
+		{3 * time.Second, false},
+	} {
+		if got := p.publish(policy, reading, start.Add(tt.after)); got != tt.want {
+			t.Errorf("publish() after %s = %v, want %v", tt.after, got, tt.want)
+		}
+	}
+
+	policy.Heartbeat = 0
+	if !p.publish(policy, reading, start.Add(3*time.Second)) {
+		t.Error("A zero heartbeat should publish every reading")
+	}
+}
+
+func TestReaderPublishesOnChange(t *testing.T) {
+	ch := make(chan Event, 20)
+	r := &Reader{broadcast: ch}
+	conf := config.Snapshot{Publicacion: config.PublishSettings{Deadband: 1, Heartbeat: time.Minute}}
+
+	for _, frame := range []string{"10.00", "10.00", "10.01", "10.02", "10.02", "kg"} {
+		r.handleFrame(conf, rhinoDriver{}, []byte(frame))
+	}
+	r.handleFrame(conf, rhinoDriver{}, []byte("10.02"))
+
+	var got []string
+	activity := 0
+	for len(ch) > 0 {
+		ev := <-ch
+		if !ev.IsError() {
+			activity++
+		}
+		if !ev.Suppressed {
+			got = append(got, ev.String())
+		}
+	}
+	// 10.01 is within one division of 10.00; the reading after an error
+	// is always sent
+	want := []string{"10.00", "10.02", ErrParse, "10.02"}
+	if len(got) != len(want) {
+		t.Fatalf("Published %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Published %v, want %v", got, want)
+			break
+		}
+	}
+	if activity != 6 {
+		t.Errorf("Expected every reading to reach the broadcaster as activity, got %d", activity)
+	}
+}
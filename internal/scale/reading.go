@@ -0,0 +1,217 @@
+package scale
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Mode indicates which weight an indicator is reporting
+type Mode string
+
+// Weighing modes reported by indicators
+const (
+	// ModeGross is the total weight on the platform.
+	ModeGross Mode = "gross"
+	// ModeNet is the gross weight minus the active tare.
+	ModeNet Mode = "net"
+	// ModeTare is the stored tare weight.
+	ModeTare Mode = "tare"
+)
+
+// ErrUnparsable is returned by drivers when a frame does not contain a weight
+var ErrUnparsable = errors.New("trama sin peso reconocible")
+
+// Reading is a single parsed weight response from a scale
+type Reading struct {
+	// Value is the weight in Unit; negative weights carry their sign.
+	Value float64
+	// Decimals is the number of decimal places reported by the indicator.
+	Decimals int
+	// Division is the scale interval in Unit. Zero means the smallest step
+	// representable with Decimals.
+	Division float64
+	// Unit is the unit reported by the indicator ("kg", "g", "lb", "oz").
+	// Empty when the protocol does not carry one.
+	Unit string
+	// Stable is true when the platform is settled. It comes from the
+	// indicator when HasStability is set and from the reader's stability
+	// detector otherwise.
+	Stable bool
+	// HasStability is true when the indicator itself reported Stable.
+	HasStability bool
+	// Mode indicates whether Value is a gross, net or tare weight.
+	Mode Mode
+	// Tare is the active tare in Unit, when the protocol reports it.
+	Tare float64
+	// OutOfRange is true when the indicator flags the weight as over
+	// capacity or under zero; Value is not trustworthy in that case.
+	OutOfRange bool
+	// Raw is the frame the reading was parsed from.
+	Raw []byte
+	// Time is when the frame was received.
+	Time time.Time
+}
+
+// String formats the reading in the v1 wire format: the bare number
+// with the indicator's own decimal places, e.g. "12.50".
+func (r Reading) String() string {
+	return strconv.FormatFloat(r.Value, 'f', r.Decimals, 64)
+}
+
+// Negative reports whether the weight is below zero
+func (r Reading) Negative() bool {
+	return r.Value < 0
+}
+
+// parseASCIIWeight extracts a reading from a free-form ASCII frame such as
+// "12.50", "  12.50 kg ST" or "ST,NT,+  1.250kg". Common status tokens
+// (ST/US for stability, GS/NT/TR for mode) are honored when present.
+// Frames without a status token are gross and carry no stability flag.
+func parseASCIIWeight(frame []byte) (Reading, error) {
+	r := Reading{
+		Mode: ModeGross,
+		Raw:  append([]byte(nil), frame...),
+	}
+
+	text := strings.TrimSpace(string(frame))
+	if text == "" {
+		return r, ErrUnparsable
+	}
+
+	for _, tok := range strings.FieldsFunc(strings.ToUpper(text), func(c rune) bool {
+		return c == ',' || unicode.IsSpace(c)
+	}) {
+		switch tok {
+		case "ST":
+			r.Stable, r.HasStability = true, true
+		case "US", "MO":
+			r.Stable, r.HasStability = false, true
+		case "GS", "GR":
+			r.Mode = ModeGross
+		case "NT":
+			r.Mode = ModeNet
+		case "TR":
+			r.Mode = ModeTare
+		}
+	}
+
+	value, decimals, rest, err := scanNumber(text)
+	if err != nil {
+		return r, err
+	}
+	r.Value = value
+	r.Decimals = decimals
+	r.Unit = scanUnit(rest)
+
+	return r, nil
+}
+
+// scanNumber finds the first signed decimal number in s, allowing blanks
+// between the sign and the digits as many indicators pad that way. Either
+// '.' or ',' is the decimal separator. A separator followed by exactly three
+// digits and then the other separator groups thousands, so "1,234.56" and
+// "1.234,56" are both 1234.56; any other number with several separators is
+// ambiguous and rejected. It returns the value, its decimal places and the
+// text after it.
+func scanNumber(s string) (float64, int, string, error) {
+	start := strings.IndexFunc(s, func(c rune) bool { return c >= '0' && c <= '9' })
+	if start < 0 {
+		return 0, 0, "", ErrUnparsable
+	}
+
+	negative := false
+	for i := start - 1; i >= 0; i-- {
+		c := s[i]
+		if c == ' ' {
+			continue
+		}
+		negative = c == '-'
+		break
+	}
+
+	// A separator belongs to the number only when a digit follows it
+	end := start
+	var seps []int
+	for ; end < len(s); end++ {
+		c := s[end]
+		if c >= '0' && c <= '9' {
+			continue
+		}
+		if (c == '.' || c == ',') && end+1 < len(s) && s[end+1] >= '0' && s[end+1] <= '9' {
+			seps = append(seps, end)
+			continue
+		}
+		break
+	}
+
+	num, decimals := s[start:end], 0
+	if len(seps) == 0 && end < len(s) && (s[end] == '.' || s[end] == ',') {
+		end++ // "12." has no decimals
+	}
+	if n := len(seps); n > 0 {
+		last := seps[n-1]
+		decimals = end - last - 1
+		if n > 1 {
+			thousands := s[seps[0]]
+			if s[last] == thousands || seps[0]-start > 3 {
+				return 0, 0, "", fmt.Errorf("%w: separadores ambiguos en %q", ErrUnparsable, num)
+			}
+			for i, sep := range seps[:n-1] {
+				if s[sep] != thousands || seps[i+1]-sep != 4 {
+					return 0, 0, "", fmt.Errorf("%w: separadores ambiguos en %q", ErrUnparsable, num)
+				}
+			}
+			num = strings.ReplaceAll(s[start:last], string(thousands), "") + s[last:end]
+		}
+		num = strings.ReplaceAll(num, ",", ".")
+	}
+
+	value, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, 0, "", fmt.Errorf("%w: %q", ErrUnparsable, num)
+	}
+	if negative {
+		value = -value
+	}
+	return value, decimals, s[end:], nil
+}
+
+// scanUnit returns the first recognized weight unit at the start of s
+func scanUnit(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for _, unit := range []string{"kg", "lb", "oz", "g"} {
+		if strings.HasPrefix(s, unit) {
+			return unit
+		}
+	}
+	return ""
+}
+
+// Event is a message published by the reader to the broadcaster: either a
+// weight reading or an ERR_* code.
+type Event struct {
+	// Reading is the published weight; zero for error events.
+	Reading Reading
+	// Code is the ERR_* code for error events; empty for weights.
+	Code string
+	// Suppressed marks a reading withheld from clients by the publishing
+	// policy. It still counts as scale activity.
+	Suppressed bool
+}
+
+// IsError reports whether the event carries an error code
+func (e Event) IsError() bool {
+	return e.Code != ""
+}
+
+// String formats the event for v1 clients: the error code or the weight
+func (e Event) String() string {
+	if e.IsError() {
+		return e.Code
+	}
+	return e.Reading.String()
+}
@@ -0,0 +1,59 @@
+package scale
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseASCIIWeight(t *testing.T) {
+	tests := []struct {
+		in       string
+		value    float64
+		decimals int
+		unit     string
+		stable   bool
+		mode     Mode
+		wire     string
+	}{
+		{"10.50", 10.50, 2, "", false, ModeGross, "10.50"},
+		{"  12.50 kg ST\r\n", 12.50, 2, "kg", true, ModeGross, "12.50"},
+		{"US,GS,+  1.250kg", 1.250, 3, "kg", false, ModeGross, "1.250"},
+		{"ST,NT,-  0.75 lb", -0.75, 2, "lb", true, ModeNet, "-0.75"},
+		{"0012", 12, 0, "", false, ModeGross, "12"},
+		{"3,5 g", 3.5, 1, "g", false, ModeGross, "3.5"},
+		{"1,234.56 kg", 1234.56, 2, "kg", false, ModeGross, "1234.56"},
+		{"1.234,56 kg", 1234.56, 2, "kg", false, ModeGross, "1234.56"},
+		{"ST,GS,-  1,234,567.8 lb", -1234567.8, 1, "lb", true, ModeGross, "-1234567.8"},
+		{"1.234.567,890", 1234567.890, 3, "", false, ModeGross, "1234567.890"},
+		{"1,234 kg", 1.234, 3, "kg", false, ModeGross, "1.234"},
+		{"12.50,ST", 12.50, 2, "", true, ModeGross, "12.50"},
+		{"12. kg", 12, 0, "kg", false, ModeGross, "12"},
+	}
+
+	for _, tt := range tests {
+		r, err := parseASCIIWeight([]byte(tt.in))
+		if err != nil {
+			t.Errorf("parseASCIIWeight(%q) returned error: %v", tt.in, err)
+			continue
+		}
+		if r.Value != tt.value || r.Decimals != tt.decimals || r.Unit != tt.unit ||
+			r.Stable != tt.stable || r.Mode != tt.mode {
+			t.Errorf("parseASCIIWeight(%q) = %+v", tt.in, r)
+		}
+		if got := r.String(); got != tt.wire {
+			t.Errorf("parseASCIIWeight(%q).String() = %q, want %q", tt.in, got, tt.wire)
+		}
+		if string(r.Raw) != tt.in {
+			t.Errorf("parseASCIIWeight(%q).Raw = %q", tt.in, r.Raw)
+		}
+	}
+}
+
+func TestParseASCIIWeightRejectsNoise(t *testing.T) {
+	for _, in := range []string{"", "   ", "\x02\x03", "ERROR",
+		"1,234,567 kg", "1.234.56", "1,23.45", "1,2345.6", "1234,567.8", "1.234,567,8", "1,234.567.8"} {
+		if _, err := parseASCIIWeight([]byte(in)); !errors.Is(err, ErrUnparsable) {
+			t.Errorf("parseASCIIWeight(%q) error = %v, want ErrUnparsable", in, err)
+		}
+	}
+}
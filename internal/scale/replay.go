@@ -0,0 +1,203 @@
+package scale
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ReplayScheme prefixes a puerto value that plays back a capture file in
+// CaptureDir instead of opening a device: "replay://bascula.cap".
+// Optional parameters: ?speed=10 plays ten times faster (0 without
+// delays) and &loop=1 restarts at the end.
+const ReplayScheme = "replay://"
+
+// IsReplayPort reports whether a puerto value names a capture to replay
+func IsReplayPort(puerto string) bool {
+	return strings.HasPrefix(strings.ToLower(puerto), ReplayScheme)
+}
+
+// errReplayPath is returned for a replay:// value without a file
+var errReplayPath = errors.New("se espera replay://archivo-de-captura")
+
+// replayOpen loads the capture named by a replay:// puerto value
+func replayOpen(puerto string) (Port, error) {
+	name, query, _ := strings.Cut(puerto[len(ReplayScheme):], "?")
+	if name == "" {
+		return nil, errReplayPath
+	}
+	path, err := ResolveFile(name)
+	if err != nil {
+		return nil, err
+	}
+	params, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("parámetros de reproducción inválidos %q: %w", query, err)
+	}
+	speed := 1.0
+	if v := params.Get("speed"); v != "" {
+		if speed, err = strconv.ParseFloat(v, 64); err != nil || speed < 0 {
+			return nil, fmt.Errorf("velocidad de reproducción inválida %q", v)
+		}
+	}
+
+	entries, err := loadCapture(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("%s: la captura no contiene tráfico", path)
+	}
+	log.Printf("[i] Reproduciendo %s: %d transferencias (velocidad x%g)", path, len(entries), speed)
+
+	return &replayPort{
+		entries: entries,
+		speed:   speed,
+		loop:    params.Get("loop") == "1",
+		base:    time.Now(),
+		written: make(chan struct{}, 1),
+		closed:  make(chan struct{}),
+	}, nil
+}
+
+// replayPort plays back RX transfers with their recorded timing. A TX
+// transfer is a sync point: playback waits there until the daemon writes,
+// so poll-mode captures answer each request like the original scale.
+// Like a serial port, Read returns 0, nil when the read timeout elapses.
+type replayPort struct {
+	entries []captureEntry
+	speed   float64
+	loop    bool
+
+	mu         sync.Mutex
+	next       int
+	base       time.Time     // wall time playback was last synced
+	baseOffset time.Duration // capture offset at base
+	pending    []byte        // rest of an RX transfer larger than the read buffer
+	timeout    time.Duration
+
+	written   chan struct{}
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func (p *replayPort) SetReadTimeout(t time.Duration) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.timeout = t
+	return nil
+}
+
+func (p *replayPort) Read(b []byte) (int, error) {
+	p.mu.Lock()
+	var deadline time.Time
+	if p.timeout > 0 {
+		deadline = time.Now().Add(p.timeout)
+	}
+	if len(p.pending) > 0 {
+		n := copy(b, p.pending)
+		p.pending = p.pending[n:]
+		p.mu.Unlock()
+		return n, nil
+	}
+	p.mu.Unlock()
+
+	for {
+		p.mu.Lock()
+		if p.next >= len(p.entries) {
+			if !p.loop {
+				p.mu.Unlock()
+				// The scale went silent
+				_, err := p.wait(nil, deadline)
+				return 0, err
+			}
+			p.next, p.base, p.baseOffset = 0, time.Now(), 0
+		}
+		entry := p.entries[p.next]
+
+		if entry.Dir == captureTX {
+			p.mu.Unlock()
+			// A write may have been signaled for an earlier sync point, so
+			// the loop checks the position again after any signal
+			fired, err := p.wait(p.written, deadline)
+			if !fired || err != nil {
+				return 0, err
+			}
+			continue
+		}
+
+		due := time.Now()
+		if p.speed > 0 {
+			due = p.base.Add(time.Duration(float64(entry.Offset-p.baseOffset) / p.speed))
+		}
+		p.mu.Unlock()
+
+		if !deadline.IsZero() && due.After(deadline) {
+			_, err := p.wait(nil, deadline)
+			return 0, err
+		}
+		if _, err := p.wait(nil, due); err != nil {
+			return 0, err
+		}
+
+		p.mu.Lock()
+		p.next++
+		n := copy(b, entry.Data)
+		p.pending = entry.Data[n:]
+		p.mu.Unlock()
+		return n, nil
+	}
+}
+
+// Write moves playback past a TX sync point. The written bytes are not
+// compared with the capture.
+func (p *replayPort) Write(b []byte) (int, error) {
+	select {
+	case <-p.closed:
+		return 0, io.ErrClosedPipe
+	default:
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.next < len(p.entries) && p.entries[p.next].Dir == captureTX {
+		p.baseOffset = p.entries[p.next].Offset
+		p.base = time.Now()
+		p.next++
+		select {
+		case p.written <- struct{}{}:
+		default:
+		}
+	}
+	return len(b), nil
+}
+
+func (p *replayPort) Close() error {
+	p.closeOnce.Do(func() { close(p.closed) })
+	return nil
+}
+
+// wait blocks until signal fires, until passes (zero means no limit) or
+// the port is closed. It reports whether signal fired.
+func (p *replayPort) wait(signal <-chan struct{}, until time.Time) (bool, error) {
+	var timer <-chan time.Time
+	if !until.IsZero() {
+		t := time.NewTimer(time.Until(until))
+		defer t.Stop()
+		timer = t.C
+	}
+	select {
+	case <-signal:
+		return true, nil
+	case <-timer:
+		return false, nil
+	case <-p.closed:
+		return false, io.ErrClosedPipe
+	}
+}
@@ -0,0 +1,316 @@
+package scale
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/adcondev/scale-daemon/internal/config"
+)
+
+// RFC2217Scheme prefixes a puerto value naming a serial device server that
+// speaks RFC 2217 (Telnet COM port control): "rfc2217://192.168.1.50:2217".
+// Unlike tcp://, the configured line settings are applied remotely.
+const RFC2217Scheme = "rfc2217://"
+
+// Telnet commands and options (RFC 854, 856, 858, 2217)
+const (
+	telnetSE   = 240
+	telnetSB   = 250
+	telnetWill = 251
+	telnetWont = 252
+	telnetDo   = 253
+	telnetDont = 254
+	telnetIAC  = 255
+
+	telnetBinary  = 0
+	telnetSGA     = 3
+	telnetComPort = 44
+)
+
+// COM-PORT-OPTION client commands; the server answers each with the
+// command code plus comPortReply.
+const (
+	comPortSetBaudRate = 1
+	comPortSetDataSize = 2
+	comPortSetParity   = 3
+	comPortSetStopSize = 4
+	comPortSetControl  = 5
+
+	comPortReply = 100
+
+	comPortControlNoFlow = 1
+)
+
+var (
+	// ErrComPortRefused is returned when the device server does not accept
+	// the COM-PORT-OPTION.
+	ErrComPortRefused = errors.New("el servidor no acepta RFC 2217 (COM-PORT-OPTION)")
+	errNegotiation    = errors.New("el servidor no confirmó la configuración RFC 2217")
+)
+
+// rfc2217Open connects to a device server, negotiates the COM port option
+// and applies the line settings, waiting for the server to confirm each.
+func rfc2217Open(addr string, line config.SerialSettings) (Port, error) {
+	conn, err := net.DialTimeout("tcp", addr, tcpTimeout)
+	if err != nil {
+		return nil, err
+	}
+	p := &rfc2217Port{tcpPort: tcpPort{conn: conn}, replies: map[byte][]byte{}}
+	if err := p.negotiate(line); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+// rfc2217Port is a tcpPort that strips Telnet commands from the data
+// stream and escapes IAC bytes on write.
+type rfc2217Port struct {
+	tcpPort
+
+	writeMu sync.Mutex
+
+	// Decoder state, only touched by the reading goroutine
+	state   rfc2217State
+	verb    byte
+	sub     []byte
+	pending []byte // data received during negotiation
+
+	comPort bool // server sent DO COM-PORT-OPTION
+	refused bool // server sent DONT COM-PORT-OPTION
+	replies map[byte][]byte
+}
+
+type rfc2217State int
+
+const (
+	stateData rfc2217State = iota
+	stateIAC
+	stateVerb
+	stateSub
+	stateSubIAC
+)
+
+func (p *rfc2217Port) negotiate(line config.SerialSettings) error {
+	err := p.sendRaw([]byte{
+		telnetIAC, telnetWill, telnetComPort,
+		telnetIAC, telnetWill, telnetBinary,
+		telnetIAC, telnetDo, telnetBinary,
+		telnetIAC, telnetWill, telnetSGA,
+		telnetIAC, telnetDo, telnetSGA,
+	})
+	if err != nil {
+		return err
+	}
+	if err := p.await(func() bool { return p.comPort || p.refused }); err != nil {
+		return err
+	}
+	if p.refused {
+		return ErrComPortRefused
+	}
+
+	baud := make([]byte, 4)
+	binary.BigEndian.PutUint32(baud, uint32(line.BaudRate)) //nolint:gosec
+	settings := []struct {
+		cmd   byte
+		value []byte
+	}{
+		{comPortSetBaudRate, baud},
+		{comPortSetDataSize, []byte{byte(line.DataBits)}}, //nolint:gosec
+		{comPortSetParity, []byte{rfc2217Parity(line.Parity)}},
+		{comPortSetStopSize, []byte{rfc2217StopSize(line.StopBits)}},
+		{comPortSetControl, []byte{comPortControlNoFlow}},
+	}
+	for _, s := range settings {
+		if err := p.sendRaw(subnegotiation(s.cmd, s.value)); err != nil {
+			return err
+		}
+	}
+	for _, s := range settings {
+		if err := p.await(func() bool { return p.replies[s.cmd+comPortReply] != nil }); err != nil {
+			return err
+		}
+		if got := p.replies[s.cmd+comPortReply]; !bytes.Equal(got, s.value) {
+			return fmt.Errorf("%w: comando %d pidió %v, el servidor aplicó %v", errNegotiation, s.cmd, s.value, got)
+		}
+	}
+	return nil
+}
+
+// await reads until done reports true or tcpTimeout elapses
+func (p *rfc2217Port) await(done func() bool) error {
+	deadline := time.Now().Add(tcpTimeout)
+	buf := make([]byte, 64)
+	for !done() {
+		if time.Now().After(deadline) {
+			return errNegotiation
+		}
+		if err := p.conn.SetReadDeadline(deadline); err != nil {
+			return err
+		}
+		n, err := p.conn.Read(buf)
+		if err != nil {
+			return fmt.Errorf("%w: %v", errNegotiation, err)
+		}
+		p.pending = append(p.pending, p.decode(buf[:n])...)
+	}
+	return nil
+}
+
+// Read returns serial data with Telnet commands removed. Like tcpPort, it
+// returns 0, nil when the read timeout elapses.
+func (p *rfc2217Port) Read(b []byte) (int, error) {
+	if len(p.pending) > 0 {
+		n := copy(b, p.pending)
+		p.pending = p.pending[n:]
+		return n, nil
+	}
+	for {
+		n, err := p.tcpPort.Read(b)
+		if n == 0 || err != nil {
+			return 0, err
+		}
+		// Decoded data is never longer than the raw bytes, so decode in place
+		if data := p.decode(b[:n]); len(data) > 0 {
+			return copy(b, data), nil
+		}
+	}
+}
+
+// Write sends serial data, escaping IAC bytes
+func (p *rfc2217Port) Write(b []byte) (int, error) {
+	escaped := bytes.ReplaceAll(b, []byte{telnetIAC}, []byte{telnetIAC, telnetIAC})
+	if err := p.sendRaw(escaped); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+func (p *rfc2217Port) sendRaw(b []byte) error {
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
+	_, err := p.tcpPort.Write(b)
+	return err
+}
+
+// decode runs raw bytes through the Telnet state machine, answering
+// option negotiation and recording COM port replies. It returns the data
+// bytes, reusing raw's storage.
+func (p *rfc2217Port) decode(raw []byte) []byte {
+	data := raw[:0]
+	for _, c := range raw {
+		switch p.state {
+		case stateData:
+			if c == telnetIAC {
+				p.state = stateIAC
+			} else {
+				data = append(data, c)
+			}
+		case stateIAC:
+			switch c {
+			case telnetIAC:
+				data = append(data, c)
+				p.state = stateData
+			case telnetWill, telnetWont, telnetDo, telnetDont:
+				p.verb = c
+				p.state = stateVerb
+			case telnetSB:
+				p.sub = p.sub[:0]
+				p.state = stateSub
+			default:
+				// NOP, GA and other two-byte commands carry no data
+				p.state = stateData
+			}
+		case stateVerb:
+			p.option(p.verb, c)
+			p.state = stateData
+		case stateSub:
+			if c == telnetIAC {
+				p.state = stateSubIAC
+			} else {
+				p.sub = append(p.sub, c)
+			}
+		case stateSubIAC:
+			switch c {
+			case telnetSE:
+				p.subnegotiation(p.sub)
+				p.state = stateData
+			case telnetIAC:
+				p.sub = append(p.sub, c)
+				p.state = stateSub
+			default:
+				p.state = stateSub
+			}
+		}
+	}
+	return data
+}
+
+// option answers a WILL/WONT/DO/DONT from the server. The options we use
+// were requested up front, so only refusals are ever sent back and
+// negotiation cannot loop.
+func (p *rfc2217Port) option(verb, opt byte) {
+	supported := opt == telnetBinary || opt == telnetSGA || opt == telnetComPort
+	switch verb {
+	case telnetDo:
+		if opt == telnetComPort {
+			p.comPort = true
+		}
+		if !supported {
+			_ = p.sendRaw([]byte{telnetIAC, telnetWont, opt})
+		}
+	case telnetDont:
+		if opt == telnetComPort {
+			p.refused = true
+		}
+	case telnetWill:
+		if !supported || opt == telnetComPort {
+			_ = p.sendRaw([]byte{telnetIAC, telnetDont, opt})
+		}
+	}
+}
+
+func (p *rfc2217Port) subnegotiation(sub []byte) {
+	if len(sub) < 2 || sub[0] != telnetComPort {
+		return
+	}
+	// Line and modem state notifications (106, 107) are kept like replies
+	// but not acted upon
+	p.replies[sub[1]] = append([]byte(nil), sub[2:]...)
+}
+
+// subnegotiation builds IAC SB COM-PORT-OPTION cmd value IAC SE
+func subnegotiation(cmd byte, value []byte) []byte {
+	msg := []byte{telnetIAC, telnetSB, telnetComPort, cmd}
+	msg = append(msg, bytes.ReplaceAll(value, []byte{telnetIAC}, []byte{telnetIAC, telnetIAC})...)
+	return append(msg, telnetIAC, telnetSE)
+}
+
+func rfc2217Parity(p string) byte {
+	switch p {
+	case "O":
+		return 2
+	case "E":
+		return 3
+	case "M":
+		return 4
+	case "S":
+		return 5
+	}
+	return 1
+}
+
+func rfc2217StopSize(s float64) byte {
+	switch s {
+	case 2:
+		return 2
+	case 1.5:
+		return 3
+	}
+	return 1
+}
@@ -0,0 +1,149 @@
+package scale
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/adcondev/scale-daemon/internal/config"
+)
+
+// fakeDeviceServer is a minimal RFC 2217 serial device server. It records
+// the line settings the client applies and echoes them back; refuse makes
+// it reject the COM port option.
+type fakeDeviceServer struct {
+	ln       net.Listener
+	refuse   bool
+	settings chan map[byte][]byte
+	received chan []byte // raw bytes read while negotiating
+	conn     chan net.Conn
+}
+
+func newFakeDeviceServer(t *testing.T, refuse bool) *fakeDeviceServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	s := &fakeDeviceServer{
+		ln:       ln,
+		refuse:   refuse,
+		settings: make(chan map[byte][]byte, 1),
+		received: make(chan []byte, 1),
+		conn:     make(chan net.Conn, 1),
+	}
+	go s.serve()
+	return s
+}
+
+func (s *fakeDeviceServer) puerto() string {
+	return RFC2217Scheme + s.ln.Addr().String()
+}
+
+func (s *fakeDeviceServer) serve() {
+	conn, err := s.ln.Accept()
+	if err != nil {
+		return
+	}
+	verb := byte(telnetDo)
+	if s.refuse {
+		verb = telnetDont
+	}
+	// Ask for an unsupported option first, which the client must decline
+	_, _ = conn.Write([]byte{telnetIAC, telnetDo, 24, telnetIAC, verb, telnetComPort})
+
+	settings := map[byte][]byte{}
+	var received []byte
+	buf := make([]byte, 1)
+	var sub []byte
+	inSub := false
+	for len(settings) < 5 {
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			return
+		}
+		c := buf[0]
+		received = append(received, c)
+		switch {
+		case inSub && c == telnetSE && len(sub) > 0 && sub[len(sub)-1] == telnetIAC:
+			sub = sub[:len(sub)-1]
+			cmd, value := sub[1], sub[2:]
+			settings[cmd] = value
+			reply := append([]byte{telnetIAC, telnetSB, telnetComPort, cmd + comPortReply}, value...)
+			_, _ = conn.Write(append(reply, telnetIAC, telnetSE))
+			inSub = false
+		case inSub:
+			sub = append(sub, c)
+		case c == telnetSB:
+			sub, inSub = nil, true
+		}
+	}
+	s.settings <- settings
+	s.received <- received
+	s.conn <- conn
+}
+
+func TestRFC2217AppliesLineSettings(t *testing.T) {
+	srv := newFakeDeviceServer(t, false)
+	line := config.SerialSettings{BaudRate: 4800, DataBits: 7, Parity: "E", StopBits: 1}
+
+	port, err := openPort(srv.puerto(), line)
+	if err != nil {
+		t.Fatalf("openPort() error: %v", err)
+	}
+	defer func() { _ = port.Close() }()
+
+	got := <-srv.settings
+	if baud := binary.BigEndian.Uint32(got[comPortSetBaudRate]); baud != 4800 {
+		t.Errorf("Baud rate applied = %d, want 4800", baud)
+	}
+	if got[comPortSetDataSize][0] != 7 || got[comPortSetParity][0] != 3 || got[comPortSetStopSize][0] != 1 {
+		t.Errorf("Line settings applied = %v, want 7 data bits, even parity, 1 stop bit", got)
+	}
+	if received := <-srv.received; !bytes.Contains(received, []byte{telnetIAC, telnetWont, 24}) {
+		t.Errorf("Expected the client to refuse the unsupported option, got %v", received)
+	}
+
+	remote := <-srv.conn
+	// A modem state notification and an escaped 0xFF inside the data
+	_, _ = remote.Write([]byte{telnetIAC, telnetSB, telnetComPort, 107, 0x30, telnetIAC, telnetSE})
+	_, _ = remote.Write([]byte{'1', '.', '5', '0', telnetIAC, telnetIAC, '\r'})
+
+	_ = port.SetReadTimeout(200 * time.Millisecond)
+	var data []byte
+	buf := make([]byte, 32)
+	for len(data) < 6 {
+		n, err := port.Read(buf)
+		if err != nil || n == 0 {
+			t.Fatalf("Read() = %d, %v after %q", n, err, data)
+		}
+		data = append(data, buf[:n]...)
+	}
+	if want := []byte{'1', '.', '5', '0', 0xFF, '\r'}; !bytes.Equal(data, want) {
+		t.Errorf("Read() data = %q, want %q", data, want)
+	}
+
+	if _, err := port.Write([]byte{'P', 0xFF}); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+	_ = remote.SetReadDeadline(time.Now().Add(time.Second))
+	raw := make([]byte, 0, 8)
+	for !bytes.HasSuffix(raw, []byte{'P', telnetIAC, telnetIAC}) {
+		n, err := remote.Read(buf)
+		if err != nil {
+			t.Fatalf("Remote read error: %v (got %v), want IAC escaped", err, raw)
+		}
+		raw = append(raw, buf[:n]...)
+	}
+}
+
+func TestRFC2217Refused(t *testing.T) {
+	srv := newFakeDeviceServer(t, true)
+	if _, err := openPort(srv.puerto(), config.DefaultSerialSettings()); !errors.Is(err, ErrComPortRefused) {
+		t.Errorf("openPort() error = %v, want ErrComPortRefused", err)
+	}
+}
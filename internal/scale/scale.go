@@ -8,8 +8,8 @@ import (
 	"io"
 	"log"
 	"math/rand/v2"
-	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.bug.st/serial"
@@ -19,67 +19,21 @@ import (
 
 // Communication constants for the scale reader
 const (
-	// RetryDelay is the delay between connection retry attempts.
-	RetryDelay = 3 * time.Second
-	// SerialReadTimeout is the timeout for serial port reads.
-	SerialReadTimeout = 5 * time.Second
-	// BaudRate is the baud rate for serial communication.
-	BaudRate = 9600
+	// ErrorRepeatInterval is how often an error code that has not changed
+	// is broadcast again; clients get every new code immediately.
+	ErrorRepeatInterval = 30 * time.Second
+	// PollInterval is the pause between weight requests in poll mode.
+	PollInterval = 300 * time.Millisecond
+	// PollResponseWait is how long an indicator that may omit its frame
+	// terminator is given to answer a poll.
+	PollResponseWait = 500 * time.Millisecond
+	// ContinuousPublishInterval limits how often the latest frame of a
+	// continuous stream is published (10 readings per second).
+	ContinuousPublishInterval = 100 * time.Millisecond
+	// readBufferSize holds several frames of a continuous stream per read.
+	readBufferSize = 256
 )
 
-// Error codes for scale communication failures
-const (
-	// ErrEOF is the error code for end-of-file received.
-	ErrEOF = "ERR_EOF"
-	// ErrTimeout is the error code for read timeout.
-	ErrTimeout = "ERR_TIMEOUT"
-	// ErrRead is the error code for read error.
-	ErrRead = "ERR_READ"
-	// ErrConnection is the error code for connection failure.
-	ErrConnection = "ERR_SCALE_CONN"
-)
-
-// ErrorDescriptions maps error codes to human-readable descriptions
-var ErrorDescriptions = map[string]string{
-	ErrEOF:        "EOF recibido. Posible desconexión.",
-	ErrTimeout:    "Timeout de lectura.",
-	ErrRead:       "Error de lectura.",
-	ErrConnection: "No se pudo conectar al puerto serial.",
-}
-
-// BrandCommands maps scale brands to their weight request commands
-var BrandCommands = map[string]string{
-	"rhino":         "P",
-	"rhino bar 8rs": "P",
-}
-
-// GetCommand returns the command for a given brand
-// Defaults to "P" if brand is unknown
-func GetCommand(brand string) string {
-	if cmd, ok := BrandCommands[strings.ToLower(brand)]; ok {
-		return cmd
-	}
-	return "P"
-}
-
-// GenerateSimulatedWeights creates a sequence of realistic weight readings
-// Returns 5 fluctuating values followed by a stable reading
-func GenerateSimulatedWeights() []float64 {
-	base := rand.Float64()*29 + 1 //nolint:gosec
-	var weights []float64
-
-	// 5 readings with small fluctuation
-	for i := 0; i < 5; i++ {
-		variation := base + rand.Float64()*0.1 - 0.05 //nolint:gosec
-		weights = append(weights, float64(int(variation*100))/100)
-	}
-
-	// Final stable reading
-	weights = append(weights, float64(int(base*100))/100)
-
-	return weights
-}
-
 // Port interface to abstract serial port dependency for testing
 type Port interface {
 	io.ReadWriteCloser
@@ -94,10 +48,65 @@ var serialOpen = func(name string, mode *serial.Mode) (Port, error) {
 // Reader manages serial port communication with the scale
 type Reader struct {
 	config    *config.Config
-	broadcast chan<- string
+	broadcast chan<- Event
 	port      Port
+	paused    bool // guarded by mu; the port stays closed while set
 	mu        sync.Mutex
 	stopCh    chan struct{}
+	ops       chan opRequest
+
+	frameErrors atomic.Uint64
+	capture     captureRecorder
+
+	// stability is owned by the read loop and recreated on every cycle
+	stability *StabilityDetector
+
+	tareMu   sync.Mutex
+	softTare softwareTare
+	last     Reading // last gross reading, for software tare
+	lastUnit string  // unit of the last reading, for hardware preset tares
+
+	// statusMu guards the reconnect backoff, the last error broadcast and
+	// the last reading published
+	statusMu    sync.Mutex
+	attempts    int       // consecutive failed connections
+	nextAttempt time.Time // zero while connected
+	lastCode    string    // cleared by the next reading
+	lastCodeAt  time.Time
+	errorCounts map[string]uint64
+	lastErr     *ScaleError
+	published   publisher
+	// watchers receive every reading and error code, sent to clients or
+	// not, while WaitStable runs
+	watchers map[chan Event]struct{}
+
+	// stateMu guards the connection state and its subscribers
+	stateMu     sync.Mutex
+	state       State
+	stateSince  time.Time
+	subscribers map[chan Transition]struct{}
+}
+
+// RetryStatus reports the reconnect backoff of a reader
+type RetryStatus struct {
+	// Attempts counts consecutive failed connections; 0 once connected.
+	Attempts int
+	// NextAttempt is when the reader reconnects; zero while connected.
+	NextAttempt time.Time
+	// LastError is the last error code broadcast since the last reading.
+	LastError string
+}
+
+// opRequest is an Operation queued for the read loop
+type opRequest struct {
+	op     Operation
+	params OperationParams
+	reply  chan opReply
+}
+
+type opReply struct {
+	result OperationResult
+	err    error
 }
 
 func (r *Reader) sleep(ctx context.Context, d time.Duration) bool {
@@ -112,16 +121,20 @@ func (r *Reader) sleep(ctx context.Context, d time.Duration) bool {
 }
 
 // NewReader creates a new scale reader
-func NewReader(cfg *config.Config, broadcast chan<- string) *Reader {
+func NewReader(cfg *config.Config, broadcast chan<- Event) *Reader {
 	return &Reader{
-		config:    cfg,
-		broadcast: broadcast,
-		stopCh:    make(chan struct{}),
+		config:     cfg,
+		broadcast:  broadcast,
+		stopCh:     make(chan struct{}),
+		ops:        make(chan opRequest),
+		state:      StateDisconnected,
+		stateSince: time.Now(),
 	}
 }
 
 // Start begins the reading loop (blocking)
 func (r *Reader) Start(ctx context.Context) {
+	defer r.setState(StateStopped, "")
 	for {
 		select {
 		case <-ctx.Done():
@@ -136,9 +149,39 @@ func (r *Reader) Start(ctx context.Context) {
 	}
 }
 
-// Stop signals the reader to stop
+// Stop signals the reader to stop and interrupts any pending read
 func (r *Reader) Stop() {
 	close(r.stopCh)
+	r.closePort()
+}
+
+// errPaused is returned by connect while the reader is paused
+var errPaused = errors.New("lector en pausa")
+
+// Pause closes the port and keeps it closed until Resume, so another
+// component (the port probe) can open it.
+func (r *Reader) Pause() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.paused = true
+	if r.port != nil {
+		_ = r.port.Close()
+		r.port = nil
+	}
+	r.setState(StateDisconnected, "")
+}
+
+// Resume lets the read loop reopen the port after Pause
+func (r *Reader) Resume() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.paused = false
+}
+
+func (r *Reader) isPaused() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.paused
 }
 
 // ClosePort closes the serial port for config changes
@@ -160,36 +203,135 @@ func (r *Reader) closePort() {
 
 func (r *Reader) readCycle(ctx context.Context) {
 	conf := r.config.Get()
+	r.stability = NewStabilityDetector(conf.Estabilidad.Window, conf.Estabilidad.Tolerance, conf.Division)
+	r.statusMu.Lock()
+	r.published.reset()
+	r.statusMu.Unlock()
 
-	// Test mode: generate simulated weights
+	// Test mode: play the simulation scenario
 	if conf.ModoPrueba {
-		log.Printf("[~] Modo prueba activado - Ambiente: %s", conf.Ambiente)
-		for _, peso := range GenerateSimulatedWeights() {
-			select {
-			case <-ctx.Done():
-				return
-			case r.broadcast <- fmt.Sprintf("%.2f", peso):
-			}
-			if !r.sleep(ctx, 300*time.Millisecond) {
-				return
-			}
-		}
-		r.sleep(ctx, RetryDelay)
+		r.resetRetry()
+		r.clearError()
+		r.setState(StateConnected, "")
+		r.simulate(ctx, conf)
 		return
 	}
 
 	// Real mode: connect to serial port
-	if err := r.connect(conf.Puerto); err != nil {
-		log.Printf("[X] No se pudo abrir el puerto serial %s: %v. Reintentando en %s...",
-			conf.Puerto, err, RetryDelay)
-		r.sendError(ErrConnection) // Notify clients of connection failure
-		r.sleep(ctx, RetryDelay)
+	if !r.isPaused() {
+		r.setState(StateConnecting, "")
+	}
+	err := r.connect(conf.Puerto, conf.Serial)
+	if errors.Is(err, errPaused) {
+		r.setState(StateDisconnected, "")
+		r.sleep(ctx, PollInterval)
+		return
+	}
+	if err != nil {
+		code := ErrConnection
+		if isPortBusy(err) {
+			code = ErrPortBusy
+		}
+		r.setState(StateBackoff, code)
+		delay, attempt := r.scheduleRetry(conf.Reintento)
+		failure := r.fail(conf.Puerto, code, err) // Notify clients of connection failure
+		log.Printf("[X] No se pudo abrir el puerto serial (%s): %v. Reintento %d en %s...",
+			conf.Serial, failure, attempt, delay)
+		r.sleep(ctx, delay)
+		return
+	}
+
+	log.Printf("[OK] Conectado al puerto serial: %s (%s)", conf.Puerto, conf.Serial)
+	r.resetRetry()
+	r.setState(StateConnected, "")
+
+	driver, err := driverFor(conf.Marca)
+	if err != nil {
+		log.Printf("[!] %v. Usando driver por defecto: %s", err, driver.Name())
+	}
+	// Closing the port unblocks a pending read as soon as ctx is canceled
+	stop := context.AfterFunc(ctx, r.closePort)
+	defer stop()
+
+	mode := resolveReadMode(conf.ModoLectura, driver)
+	log.Printf("[i] Driver: %s | Modo de lectura: %s", driver.Name(), mode)
+
+	if mode == config.ReadModeContinuous {
+		r.readContinuous(ctx, conf, driver)
+	} else {
+		r.readPolled(ctx, conf, driver)
+	}
+
+	if ctx.Err() != nil {
 		return
 	}
+	if r.isPaused() {
+		// Closed on purpose for a port probe: not a failed connection
+		r.setState(StateDisconnected, "")
+		r.sleep(ctx, PollInterval)
+		return
+	}
+	r.setState(StateBackoff, r.RetryStatus().LastError)
+	delay, _ := r.scheduleRetry(conf.Reintento)
+	log.Printf("[~] Esperando %s antes de intentar reconectar al puerto serial...", delay)
+	r.sleep(ctx, delay)
+}
+
+// scheduleRetry counts a failed connection and returns the backoff delay
+// before the next attempt, with jitter, and the attempt number
+func (r *Reader) scheduleRetry(retry config.RetrySettings) (time.Duration, int) {
+	r.statusMu.Lock()
+	defer r.statusMu.Unlock()
+	r.attempts++
+	delay := retry.Delay(r.attempts)
+	if retry.Jitter > 0 {
+		delay += time.Duration((rand.Float64()*2 - 1) * retry.Jitter * float64(delay)) //nolint:gosec
+	}
+	delay = delay.Round(time.Millisecond)
+	r.nextAttempt = time.Now().Add(delay)
+	return delay, r.attempts
+}
+
+func (r *Reader) resetRetry() {
+	r.statusMu.Lock()
+	defer r.statusMu.Unlock()
+	r.attempts = 0
+	r.nextAttempt = time.Time{}
+}
+
+// RetryStatus returns the reconnect backoff state
+func (r *Reader) RetryStatus() RetryStatus {
+	r.statusMu.Lock()
+	defer r.statusMu.Unlock()
+	return RetryStatus{Attempts: r.attempts, NextAttempt: r.nextAttempt, LastError: r.lastCode}
+}
+
+// resolveReadMode picks the configured read mode, or the driver's natural
+// one in auto mode: continuous when it has no poll command.
+func resolveReadMode(configured config.ReadMode, driver Driver) config.ReadMode {
+	canPoll := len(driver.Command()) > 0
+	switch configured {
+	case config.ReadModeContinuous:
+		return config.ReadModeContinuous
+	case config.ReadModePoll:
+		if canPoll {
+			return config.ReadModePoll
+		}
+		log.Printf("[!] El driver %s no admite sondeo, usando lectura continua", driver.Name())
+		return config.ReadModeContinuous
+	}
+	if canPoll {
+		return config.ReadModePoll
+	}
+	return config.ReadModeContinuous
+}
 
-	log.Printf("[OK] Conectado al puerto serial: %s", conf.Puerto)
+// readPolled requests a weight, waits for the reply and repeats every
+// PollInterval until the port closes or ctx is canceled.
+func (r *Reader) readPolled(ctx context.Context, conf config.Snapshot, driver Driver) {
+	framing := driver.Framing()
+	framer := NewFramer(framing)
 
-	// Read loop
 	for {
 		select {
 		case <-ctx.Done():
@@ -197,107 +339,431 @@ func (r *Reader) readCycle(ctx context.Context) {
 		default:
 		}
 
-		// Check if config changed
-		newConf := r.config.Get()
+		// Run queued operations between polls so replies are not mixed up
+		select {
+		case req := <-r.ops:
+			req.reply <- r.runOperation(ctx, driver, req)
+		default:
+		}
 
 		r.mu.Lock()
 		if r.port == nil {
 			r.mu.Unlock()
 			log.Println("[i] Puerto serial cerrado, saliendo del bucle de lectura.")
-			break
+			return
 		}
 
 		// Send weight request command
-		cmd := GetCommand(newConf.Marca)
-		_, err := r.port.Write([]byte(cmd))
+		_, err := r.port.Write(driver.Command())
 		if err != nil {
 			log.Printf("[!] Error al escribir en el puerto: %v. Cerrando y reintentando...", err)
 			err := r.port.Close()
 			if err != nil {
+				r.mu.Unlock()
 				return
 			}
 			r.port = nil
 			r.mu.Unlock()
-			r.sleep(ctx, RetryDelay)
-			break
+			return
 		}
 
 		r.mu.Unlock()
 
-		if !r.sleep(ctx, 500*time.Millisecond) {
+		// Indicators that may omit the terminator get a fixed time to answer;
+		// the rest are read until their frame completes.
+		if framing.FlushOnIdle && !r.sleep(ctx, PollResponseWait) {
 			return
 		}
 
-		r.mu.Lock()
-		if r.port == nil {
-			r.mu.Unlock()
-			break
+		port := r.currentPort()
+		if port == nil {
+			return
 		}
 
-		// Read response
-		buf := make([]byte, 20)
-		n, err := r.port.Read(buf)
-		r.mu.Unlock()
-
+		// Read response, reassembling frames split across reads
+		frames, err := r.readFrames(port, framer, !framing.FlushOnIdle, conf.Serial.ReadTimeout)
 		if err != nil {
-			switch {
-			case errors.Is(err, io.EOF):
-				log.Printf("[!] %s: %s", ErrorDescriptions[ErrEOF], conf.Puerto)
-				r.sendError(ErrEOF)
-			case strings.Contains(err.Error(), "timeout"):
-				log.Printf("[~] %s: %s. Reintentando...", ErrorDescriptions[ErrTimeout], conf.Puerto)
-				r.sendError(ErrTimeout)
-				continue
-			default:
-				log.Printf("[!] %s: %s - %v", ErrorDescriptions[ErrRead], conf.Puerto, err)
-				r.sendError(ErrRead)
-				r.closePort()
-				r.sleep(ctx, RetryDelay)
+			if !r.handleReadError(ctx, conf, err) {
+				return
 			}
 			continue
 		}
 
-		peso := strings.TrimSpace(string(buf[:n]))
-		if peso != "" {
-			log.Printf("[>] Peso enviado: %s", peso)
-			select {
-			case r.broadcast <- peso:
-			default:
-				// Channel full, skip
+		if len(frames) == 0 {
+			if frame := framer.Flush(); frame != nil {
+				frames = append(frames, frame)
 			}
-		} else {
+		}
+		switch {
+		case len(frames) > 0:
+			// Only the most recent frame is current
+			r.handleFrame(conf, driver, frames[len(frames)-1])
+		case framer.Pending() == 0:
 			log.Println("[!] No se recibió peso significativo.")
 		}
 
-		if !r.sleep(ctx, 300*time.Millisecond) {
+		if !r.sleep(ctx, PollInterval) {
+			return
+		}
+	}
+}
+
+// readContinuous consumes a stream from an indicator that sends frames
+// unprompted. Every byte is read as it arrives so the port buffer never
+// fills; only the latest frame is kept and it is published at most every
+// ContinuousPublishInterval.
+func (r *Reader) readContinuous(ctx context.Context, conf config.Snapshot, driver Driver) {
+	framer := NewFramer(driver.Framing())
+	var latest []byte
+	var lastPublish time.Time
+	lastFrame := time.Now()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.stopCh:
+			return
+		case req := <-r.ops:
+			req.reply <- r.runOperation(ctx, driver, req)
+			framer.Reset()
+		default:
+		}
+
+		port := r.currentPort()
+		if port == nil {
+			log.Println("[i] Puerto serial cerrado, saliendo del bucle de lectura.")
 			return
 		}
+
+		frames, err := r.readFrames(port, framer, false, conf.Serial.ReadTimeout)
+		if errors.Is(err, errReadTimeout) {
+			// Silence is measured against the last frame below
+			err = nil
+		}
+		if err != nil {
+			if !r.handleReadError(ctx, conf, err) {
+				return
+			}
+			continue
+		}
+
+		now := time.Now()
+		if len(frames) > 0 {
+			latest = frames[len(frames)-1]
+			lastFrame = now
+		} else if now.Sub(lastFrame) > conf.Serial.ReadTimeout {
+			// The indicator stopped streaming
+			r.setState(StateStalled, ErrTimeout)
+			failure := r.fail(conf.Puerto, ErrTimeout, errors.New("el indicador dejó de transmitir"))
+			log.Printf("[~] %v. Reintentando...", failure)
+			lastFrame = now
+		}
+
+		if latest != nil && now.Sub(lastPublish) >= ContinuousPublishInterval {
+			r.handleFrame(conf, driver, latest)
+			latest = nil
+			lastPublish = now
+		}
+	}
+}
+
+// handleReadError reports a failed read to clients. It returns false when
+// the read loop must exit because the port is gone.
+func (r *Reader) handleReadError(ctx context.Context, conf config.Snapshot, err error) bool {
+	if r.currentPort() == nil {
+		// Port closed for a config change or shutdown
+		return false
+	}
+	code := ErrorCode(err)
+	if code == ErrEOF || code == ErrTimeout {
+		r.setState(StateStalled, code)
+	}
+	failure := r.fail(conf.Puerto, code, err)
+	switch code {
+	case ErrEOF:
+		log.Printf("[!] %v", failure)
+	case ErrTimeout:
+		log.Printf("[~] %v. Reintentando...", failure)
+	default:
+		log.Printf("[!] %v", failure)
+		r.closePort()
+	}
+	return true
+}
+
+// currentPort returns the open port, or nil once it has been closed
+func (r *Reader) currentPort() Port {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.port
+}
+
+// readFrames reads from the port and feeds the framer. When untilFrame is
+// set, it keeps reading until a frame completes, the port reports no data
+// or timeout elapses; otherwise a single read is made. Garbled frames are
+// counted and reported to clients as ERR_READ. A read timeout with nothing
+// received returns errReadTimeout.
+//
+// The port is read without holding r.mu so ClosePort can interrupt a
+// blocking read.
+func (r *Reader) readFrames(port Port, framer *Framer, untilFrame bool, timeout time.Duration) ([][]byte, error) {
+	buf := make([]byte, readBufferSize)
+	deadline := time.Now().Add(timeout)
+
+	for {
+		n, err := port.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+
+		if n == 0 && framer.Pending() == 0 {
+			return nil, errReadTimeout
+		}
+
+		frames, garbled := framer.Feed(buf[:n])
+		if garbled > 0 {
+			total := r.frameErrors.Add(uint64(garbled))
+			log.Printf("[!] %s: %d trama(s) corrupta(s) descartada(s) (total: %d)",
+				ErrorDescriptions[ErrRead], garbled, total)
+			r.fail("", ErrRead, fmt.Errorf("%d trama(s) corrupta(s)", garbled))
+		}
+
+		if len(frames) > 0 || !untilFrame || n == 0 || time.Now().After(deadline) {
+			return frames, nil
+		}
+	}
+}
+
+// handleFrame parses a frame and broadcasts the weight or the error code
+func (r *Reader) handleFrame(conf config.Snapshot, driver Driver, frame []byte) {
+	// Any frame, even one carrying a status code, shows the indicator answers
+	r.setState(StateReading, "")
+	reading, err := driver.Parse(frame)
+	if err != nil {
+		code := ErrParse
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) {
+			code = statusErr.Code
+		}
+		failure := r.fail(conf.Puerto, code, err)
+		log.Printf("[!] %v [%s]", failure, driver.Name())
+		return
+	}
+
+	reading.Time = time.Now()
+	if code := RangeCode(conf.Rango, reading); code != "" {
+		failure := r.fail(conf.Puerto, code, fmt.Errorf("peso %s", reading))
+		log.Printf("[!] %v [%s]", failure, driver.Name())
+		return
+	}
+	r.clearError()
+	ev := r.event(conf, reading)
+	if !ev.Suppressed {
+		log.Printf("[>] Peso enviado: %s (estable: %v)", reading, ev.Reading.Stable)
+	}
+	// Suppressed readings still reach the broadcaster as scale activity
+	select {
+	case r.broadcast <- ev:
+	default:
+		// Channel full, skip
+	}
+}
+
+// event runs a reading through the stability detector and the publishing
+// policy. The event is suppressed when the reading is in motion and only
+// stable readings are wanted, or when it did not change since the last one
+// sent and the heartbeat is not due.
+func (r *Reader) event(conf config.Snapshot, reading Reading) Event {
+	if reading.Division == 0 {
+		reading.Division = conf.Division
+	}
+	if reading.Unit == "" {
+		reading.Unit = conf.Unidad
+	}
+	if r.stability != nil {
+		reading.Stable = r.stability.Update(reading)
+	}
+	reading = r.applySoftwareTare(reading)
+
+	r.statusMu.Lock()
+	defer r.statusMu.Unlock()
+	ev := Event{Reading: reading, Suppressed: true}
+	if !conf.Estabilidad.StableOnly || reading.Stable {
+		ev.Suppressed = !r.published.publish(conf.Publicacion, reading, time.Now())
+	}
+	r.notify(ev)
+	return ev
+}
+
+// FrameErrors returns the number of garbled frames discarded since start
+func (r *Reader) FrameErrors() uint64 {
+	return r.frameErrors.Load()
+}
+
+// Execute performs an indicator operation (tare, zero...) between weight
+// polls and returns the indicator's reply. Tare operations the driver does
+// not support, and every operation in test mode, fall back to a software
+// tare kept by the reader; other unsupported operations fail immediately.
+func (r *Reader) Execute(ctx context.Context, op Operation, params OperationParams) (OperationResult, error) {
+	conf := r.config.Get()
+	driver, _ := driverFor(conf.Marca)
+	operator, ok := driver.(Operator)
+	if conf.ModoPrueba || !ok {
+		return r.softwareOperation(op, params)
+	}
+	if params.Unit == "" {
+		params.Unit = r.currentUnit()
+	}
+	if _, err := operator.OperationCommand(op, params); err != nil {
+		if errors.Is(err, ErrUnsupportedOperation) {
+			return r.softwareOperation(op, params)
+		}
+		return OperationResult{}, err
+	}
+
+	if r.currentPort() == nil {
+		return OperationResult{}, errNotConnected
+	}
+
+	req := opRequest{op: op, params: params, reply: make(chan opReply, 1)}
+	select {
+	case r.ops <- req:
+	case <-ctx.Done():
+		return OperationResult{}, ctx.Err()
+	}
+	select {
+	case rep := <-req.reply:
+		if rep.err == nil && isTareOperation(op) {
+			// The indicator now reports net weights itself
+			r.setSoftwareTare(softwareTare{})
+		}
+		return rep.result, rep.err
+	case <-ctx.Done():
+		return OperationResult{}, ctx.Err()
+	}
+}
+
+// errNotConnected is returned by operations requested while the port is closed
+var errNotConnected = &StatusError{Code: ErrConnection, Detail: "puerto serial no conectado"}
+
+// runOperation writes an operation command and parses the reply.
+// It must only be called from the read loop.
+func (r *Reader) runOperation(ctx context.Context, driver Driver, req opRequest) opReply {
+	operator, ok := driver.(Operator)
+	if !ok {
+		return opReply{err: fmt.Errorf("%w: %s (%s)", ErrUnsupportedOperation, req.op, driver.Name())}
+	}
+	cmd, err := operator.OperationCommand(req.op, req.params)
+	if err != nil {
+		return opReply{err: err}
+	}
+
+	r.mu.Lock()
+	if r.port == nil {
+		r.mu.Unlock()
+		return opReply{err: errNotConnected}
+	}
+	_, err = r.port.Write(cmd)
+	r.mu.Unlock()
+	if err != nil {
+		return opReply{err: err}
+	}
+
+	if driver.Framing().FlushOnIdle && !r.sleep(ctx, PollResponseWait) {
+		return opReply{err: context.Canceled}
+	}
+
+	port := r.currentPort()
+	if port == nil {
+		return opReply{err: errNotConnected}
+	}
+	framer := NewFramer(driver.Framing())
+	frames, err := r.readFrames(port, framer, true, r.config.Get().Serial.ReadTimeout)
+	if err != nil {
+		return opReply{err: err}
+	}
+	if len(frames) == 0 {
+		if frame := framer.Flush(); frame != nil {
+			frames = append(frames, frame)
+		}
+	}
+	if len(frames) == 0 {
+		return opReply{err: &StatusError{Code: ErrTimeout, Detail: "el indicador no respondió a " + string(req.op)}}
 	}
 
-	log.Printf("[~] Esperando %s antes de intentar reconectar al puerto serial...", RetryDelay)
-	r.sleep(ctx, RetryDelay)
+	res, err := operator.ParseOperation(req.op, frames[len(frames)-1])
+	log.Printf("[i] Operación %s (%s): %+v err=%v", req.op, driver.Name(), res, err)
+	return opReply{result: res, err: err}
 }
 
+// sendError broadcasts an error code when it differs from the last one,
+// or every ErrorRepeatInterval while it persists, so a scale unplugged
+// overnight does not flood clients with one error per attempt.
 func (r *Reader) sendError(code string) {
+	r.statusMu.Lock()
+	defer r.statusMu.Unlock()
+	if code == r.lastCode && time.Since(r.lastCodeAt) < ErrorRepeatInterval {
+		return
+	}
 	select {
-	case r.broadcast <- code:
+	case r.broadcast <- Event{Code: code}:
+		r.lastCode, r.lastCodeAt = code, time.Now()
+		// Clients now show the error; the next reading must replace it
+		r.published.reset()
 	default:
 		// Channel full, skip
 	}
 }
 
-func (r *Reader) connect(puerto string) error {
-	mode := &serial.Mode{BaudRate: BaudRate}
+// clearError lets the next error be broadcast immediately
+func (r *Reader) clearError() {
+	r.statusMu.Lock()
+	defer r.statusMu.Unlock()
+	r.lastCode = ""
+}
 
+// serialMode converts configured line settings to a serial.Mode
+func serialMode(s config.SerialSettings) *serial.Mode {
+	mode := &serial.Mode{
+		BaudRate: s.BaudRate,
+		DataBits: s.DataBits,
+		Parity:   serial.NoParity,
+		StopBits: serial.OneStopBit,
+	}
+	switch s.Parity {
+	case "E":
+		mode.Parity = serial.EvenParity
+	case "O":
+		mode.Parity = serial.OddParity
+	case "M":
+		mode.Parity = serial.MarkParity
+	case "S":
+		mode.Parity = serial.SpaceParity
+	}
+	switch s.StopBits {
+	case 1.5:
+		mode.StopBits = serial.OnePointFiveStopBits
+	case 2:
+		mode.StopBits = serial.TwoStopBits
+	}
+	return mode
+}
+
+func (r *Reader) connect(puerto string, line config.SerialSettings) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	port, err := serialOpen(puerto, mode)
+	if r.paused {
+		return errPaused
+	}
+	r.syncCapture(r.config.Get().Captura)
+	port, err := openPort(puerto, line)
 	if err != nil {
 		return err
 	}
 
-	if err := port.SetReadTimeout(SerialReadTimeout); err != nil {
+	if err := port.SetReadTimeout(line.ReadTimeout); err != nil {
 		err := port.Close()
 		if err != nil {
 			return err
@@ -305,6 +771,7 @@ func (r *Reader) connect(puerto string) error {
 		return err
 	}
 
-	r.port = port
+	r.capture.note("abierto %s (%s)", puerto, line)
+	r.port = &capturePort{Port: port, rec: &r.capture}
 	return nil
 }
@@ -63,7 +63,7 @@ func TestLockContention(t *testing.T) {
 		return mockPort, nil
 	}
 
-	broadcast := make(chan string, 10)
+	broadcast := make(chan Event, 10)
 	r := NewReader(cfg, broadcast)
 
 	ctx, cancel := context.WithCancel(context.Background())
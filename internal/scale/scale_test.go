@@ -1,8 +1,15 @@
 package scale
 
 import (
+	"context"
+	"errors"
+	"strings"
 	"testing"
 	"time"
+
+	"go.bug.st/serial"
+
+	"github.com/adcondev/scale-daemon/internal/config"
 )
 
 func TestErrorConstants(t *testing.T) {
@@ -29,7 +36,7 @@ func TestErrorConstants(t *testing.T) {
 
 func TestSendError(t *testing.T) {
 	// Create a buffered channel to receive the error
-	ch := make(chan string, 1)
+	ch := make(chan Event, 1)
 
 	// Create a Reader with the channel
 	r := &Reader{
@@ -42,7 +49,7 @@ func TestSendError(t *testing.T) {
 	// Check if the error was received
 	select {
 	case msg := <-ch:
-		if msg != ErrEOF {
+		if msg.Code != ErrEOF {
 			t.Errorf("Expected message '%s', got '%s'", ErrEOF, msg)
 		}
 	case <-time.After(1 * time.Second):
@@ -51,7 +58,7 @@ func TestSendError(t *testing.T) {
 
 	// Test non-blocking behavior
 	// Fill the channel
-	ch <- "full"
+	ch <- Event{Code: "full"}
 
 	// Try to send another error, should not block
 	done := make(chan bool)
@@ -67,3 +74,87 @@ func TestSendError(t *testing.T) {
 		t.Error("sendError blocked when channel was full")
 	}
 }
+
+func TestSendErrorDeduplicates(t *testing.T) {
+	ch := make(chan Event, 10)
+	r := &Reader{broadcast: ch}
+
+	for _, code := range []string{ErrConnection, ErrConnection, ErrConnection, ErrTimeout} {
+		r.sendError(code)
+	}
+	r.clearError() // a reading arrived
+	r.sendError(ErrTimeout)
+
+	var got []string
+	for len(ch) > 0 {
+		got = append(got, (<-ch).Code)
+	}
+	want := []string{ErrConnection, ErrTimeout, ErrTimeout}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Broadcast %v, want %v", got, want)
+	}
+}
+
+func TestReaderBacksOffWhileDisconnected(t *testing.T) {
+	origSerialOpen := serialOpen
+	defer func() { serialOpen = origSerialOpen }()
+	attempts := make(chan time.Time, 10)
+	serialOpen = func(_ string, _ *serial.Mode) (Port, error) {
+		attempts <- time.Now()
+		return nil, errors.New("puerto desconectado")
+	}
+
+	cfg := config.New(config.Environment{DefaultPort: "COM_TEST"})
+	if _, err := cfg.UpdateRetry(config.RetrySettings{
+		Initial: 100 * time.Millisecond, Max: 400 * time.Millisecond, Multiplier: 2,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	broadcast := make(chan Event, 10)
+	r := NewReader(cfg, broadcast)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.Start(ctx)
+
+	var times []time.Time
+	for len(times) < 5 {
+		select {
+		case at := <-attempts:
+			times = append(times, at)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Timed out after %d attempts", len(times))
+		}
+	}
+	// 100, 200, 400 then capped at 400 ms
+	for i, want := range []time.Duration{100, 200, 400, 400} {
+		want *= time.Millisecond
+		if gap := times[i+1].Sub(times[i]); gap < want-20*time.Millisecond || gap > want+80*time.Millisecond {
+			t.Errorf("Gap before attempt %d = %s, want about %s", i+2, gap, want)
+		}
+	}
+
+	if n := len(broadcast); n != 1 {
+		t.Errorf("Broadcast %d errors for 5 failed attempts, want 1", n)
+	}
+	status := r.RetryStatus()
+	if status.Attempts < 5 || status.NextAttempt.IsZero() || status.LastError != ErrConnection {
+		t.Errorf("RetryStatus() = %+v", status)
+	}
+}
+
+func TestSerialMode(t *testing.T) {
+	mode := serialMode(config.SerialSettings{BaudRate: 4800, DataBits: 7, Parity: "E", StopBits: 1})
+	if mode.BaudRate != 4800 || mode.DataBits != 7 || mode.Parity != serial.EvenParity || mode.StopBits != serial.OneStopBit {
+		t.Errorf("serialMode(4800 7E1) = %+v", mode)
+	}
+
+	mode = serialMode(config.DefaultSerialSettings())
+	if mode.BaudRate != 9600 || mode.DataBits != 8 || mode.Parity != serial.NoParity || mode.StopBits != serial.OneStopBit {
+		t.Errorf("serialMode(default) = %+v", mode)
+	}
+
+	mode = serialMode(config.SerialSettings{BaudRate: 19200, DataBits: 8, Parity: "O", StopBits: 2})
+	if mode.Parity != serial.OddParity || mode.StopBits != serial.TwoStopBits {
+		t.Errorf("serialMode(19200 8O2) = %+v", mode)
+	}
+}
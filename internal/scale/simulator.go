@@ -0,0 +1,381 @@
+package scale
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"math/rand/v2"
+	"os"
+	"time"
+
+	"github.com/adcondev/scale-daemon/internal/config"
+)
+
+// Scenario step types
+const (
+	// StepWeight moves to a weight, settling over SettleMs, and holds it
+	StepWeight = "peso"
+	// StepOverload reports the current weight as out of range
+	StepOverload = "sobrecarga"
+	// StepError broadcasts an error code, e.g. ERR_EOF for a cable pull
+	StepError = "error"
+	// StepPause sends nothing, like an indicator that stopped answering;
+	// past the read timeout the reader stalls with ERR_TIMEOUT
+	StepPause = "pausa"
+)
+
+// Scenario limits
+const (
+	defaultSimInterval = 300
+	defaultSimPause    = 3000
+	minSimInterval     = 10
+	maxSimDecimals     = 6
+)
+
+// ErrInvalidScenario is returned by LoadScenario for malformed scenarios
+var ErrInvalidScenario = errors.New("escenario de simulación inválido")
+
+// Scenario is a scripted sequence of scale states for test mode. It is
+// loaded from a JSON file named by the escenario setting:
+//
+//	{
+//	  "nombre": "colocar-y-retirar",
+//	  "semilla": 42,
+//	  "repetir": true,
+//	  "pasos": [
+//	    {"tipo": "peso", "peso": 0, "duracionMs": 1000},
+//	    {"tipo": "peso", "peso": 2.5, "asentamientoMs": 900, "ruido": 0.02, "duracionMs": 1200},
+//	    {"tipo": "peso", "duracionMs": 2000},
+//	    {"tipo": "error", "codigo": "ERR_EOF", "duracionMs": 1000}
+//	  ]
+//	}
+type Scenario struct {
+	Name       string         `json:"nombre"`
+	Seed       uint64         `json:"semilla,omitempty"`     // 0 picks a random seed
+	Loop       bool           `json:"repetir,omitempty"`     // otherwise the last step holds forever
+	IntervalMs int            `json:"intervaloMs,omitempty"` // between readings, 300 by default
+	Unit       string         `json:"unidad,omitempty"`      // kg by default
+	Decimals   *int           `json:"decimales,omitempty"`   // 2 by default
+	Steps      []ScenarioStep `json:"pasos"`
+}
+
+// ScenarioStep is one state of a Scenario
+type ScenarioStep struct {
+	Type       string `json:"tipo"`
+	DurationMs int    `json:"duracionMs"`
+
+	// StepWeight: target weight, or the previous one when omitted. With
+	// WeightMax the target is drawn from [Weight, WeightMax] on every pass.
+	Weight    *float64 `json:"peso,omitempty"`
+	WeightMax float64  `json:"pesoMax,omitempty"`
+	// SettleMs moves linearly from the previous weight; readings are in
+	// motion until it elapses. Noise adds a uniform ±Noise variation and
+	// keeps readings in motion.
+	SettleMs int     `json:"asentamientoMs,omitempty"`
+	Noise    float64 `json:"ruido,omitempty"`
+
+	// StepError: error code to broadcast
+	Code string `json:"codigo,omitempty"`
+}
+
+// DefaultScenario reproduces the original test mode: five readings
+// fluctuating around a random weight between 1 and 30 kg, one stable
+// reading, then a pause before the next weight.
+func DefaultScenario() *Scenario {
+	base := 1.0
+	return &Scenario{
+		Name: "aleatorio",
+		Loop: true,
+		Steps: []ScenarioStep{
+			{Type: StepWeight, Weight: &base, WeightMax: 30, Noise: 0.05, DurationMs: 5 * defaultSimInterval},
+			{Type: StepWeight, DurationMs: defaultSimInterval},
+			{Type: StepPause, DurationMs: defaultSimPause},
+		},
+	}
+}
+
+// LoadNamedScenario loads a scenario file from CaptureDir. Use it for names
+// coming from the configuration.
+func LoadNamedScenario(name string) (*Scenario, error) {
+	path, err := ResolveFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return LoadScenario(path)
+}
+
+// LoadScenario reads and validates a scenario file at any path, for the
+// emulator command line. The daemon uses LoadNamedScenario.
+func LoadScenario(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // path comes from the command line
+	if err != nil {
+		return nil, err
+	}
+	var s Scenario
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidScenario, path, err)
+	}
+	if s.Name == "" {
+		s.Name = path
+	}
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Validate checks the scenario settings and every step
+func (s *Scenario) Validate() error {
+	if len(s.Steps) == 0 {
+		return fmt.Errorf("%w: sin pasos", ErrInvalidScenario)
+	}
+	if s.IntervalMs != 0 && s.IntervalMs < minSimInterval {
+		return fmt.Errorf("%w: intervaloMs %d menor a %d", ErrInvalidScenario, s.IntervalMs, minSimInterval)
+	}
+	if s.Unit != "" {
+		if _, err := ParseUnit(s.Unit); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidScenario, err)
+		}
+	}
+	if s.Decimals != nil && (*s.Decimals < 0 || *s.Decimals > maxSimDecimals) {
+		return fmt.Errorf("%w: decimales %d fuera de 0-%d", ErrInvalidScenario, *s.Decimals, maxSimDecimals)
+	}
+	for i, step := range s.Steps {
+		if err := step.validate(); err != nil {
+			return fmt.Errorf("%w: paso %d: %v", ErrInvalidScenario, i+1, err)
+		}
+	}
+	return nil
+}
+
+func (st ScenarioStep) validate() error {
+	if st.DurationMs <= 0 {
+		return errors.New("duracionMs debe ser mayor a 0")
+	}
+	switch st.Type {
+	case StepWeight:
+		if st.Noise < 0 || st.SettleMs < 0 {
+			return errors.New("ruido y asentamientoMs no pueden ser negativos")
+		}
+		if st.WeightMax != 0 && (st.Weight == nil || st.WeightMax < *st.Weight) {
+			return errors.New("pesoMax requiere un peso menor o igual")
+		}
+	case StepError:
+		if _, ok := ErrorDescriptions[st.Code]; !ok {
+			return fmt.Errorf("código de error desconocido %q", st.Code)
+		}
+	case StepOverload, StepPause:
+	default:
+		return fmt.Errorf("tipo de paso desconocido %q", st.Type)
+	}
+	return nil
+}
+
+// SimTick is one output of the simulator: a reading, an error code or
+// nothing (a pause), followed by a wait before the next tick
+type SimTick struct {
+	Reading *Reading
+	Code    string
+	Wait    time.Duration
+}
+
+// Simulator plays a Scenario. Runs with the same seed produce the same
+// ticks.
+type Simulator struct {
+	scenario *Scenario
+	seed     uint64
+	rng      *rand.Rand
+	interval time.Duration
+	unit     string
+	decimals int
+
+	step    int
+	elapsed time.Duration // within the current step
+	from    float64       // weight when the step started
+	target  float64
+	current float64
+	done    bool // a non-looping scenario reached its last step
+}
+
+// NewSimulator prepares a scenario for playback
+func NewSimulator(s *Scenario) *Simulator {
+	seed := s.Seed
+	if seed == 0 {
+		seed = rand.Uint64() //nolint:gosec
+	}
+	sim := &Simulator{
+		scenario: s,
+		seed:     seed,
+		rng:      rand.New(rand.NewPCG(seed, seed)), //nolint:gosec
+		interval: time.Duration(cmp.Or(s.IntervalMs, defaultSimInterval)) * time.Millisecond,
+		unit:     UnitKilogram,
+		decimals: 2,
+	}
+	if s.Unit != "" {
+		sim.unit, _ = ParseUnit(s.Unit)
+	}
+	if s.Decimals != nil {
+		sim.decimals = *s.Decimals
+	}
+	sim.startStep()
+	return sim
+}
+
+// Seed returns the seed in use, to reproduce a run with a random seed
+func (sim *Simulator) Seed() uint64 {
+	return sim.seed
+}
+
+// Next returns the next tick
+func (sim *Simulator) Next() SimTick {
+	st := sim.scenario.Steps[sim.step]
+	tick := SimTick{Wait: sim.interval}
+
+	switch st.Type {
+	case StepWeight:
+		settled := sim.elapsed >= time.Duration(st.SettleMs)*time.Millisecond
+		value := sim.target
+		if !settled {
+			progress := float64(sim.elapsed) / float64(time.Duration(st.SettleMs)*time.Millisecond)
+			value = sim.from + (sim.target-sim.from)*progress
+		}
+		if st.Noise > 0 {
+			value += (sim.rng.Float64()*2 - 1) * st.Noise
+		}
+		sim.current = value
+		tick.Reading = sim.reading(value, settled && st.Noise == 0)
+	case StepOverload:
+		tick.Reading = sim.reading(sim.current, false)
+		tick.Reading.OutOfRange = true
+	case StepError:
+		tick.Code = st.Code
+	case StepPause:
+		tick.Wait = time.Duration(st.DurationMs) * time.Millisecond
+	}
+
+	sim.elapsed += tick.Wait
+	if !sim.done && sim.elapsed >= time.Duration(st.DurationMs)*time.Millisecond {
+		sim.advance()
+	}
+	return tick
+}
+
+func (sim *Simulator) reading(value float64, stable bool) *Reading {
+	factor := math.Pow10(sim.decimals)
+	return &Reading{
+		Value:        math.Round(value*factor) / factor,
+		Decimals:     sim.decimals,
+		Unit:         sim.unit,
+		Stable:       stable,
+		HasStability: true,
+		Mode:         ModeGross,
+		Time:         time.Now(),
+	}
+}
+
+// advance moves to the next step; the last step of a non-looping scenario
+// holds forever
+func (sim *Simulator) advance() {
+	next := sim.step + 1
+	if next == len(sim.scenario.Steps) {
+		if !sim.scenario.Loop {
+			sim.done = true
+			return
+		}
+		next = 0
+	}
+	sim.step = next
+	sim.startStep()
+}
+
+func (sim *Simulator) startStep() {
+	st := sim.scenario.Steps[sim.step]
+	sim.elapsed = 0
+	sim.from = sim.current
+	if st.Type != StepWeight {
+		return
+	}
+	if st.Weight != nil {
+		sim.target = *st.Weight
+		if st.WeightMax != 0 {
+			sim.target += sim.rng.Float64() * (st.WeightMax - *st.Weight)
+		}
+	}
+	if st.SettleMs == 0 {
+		sim.from = sim.target
+	}
+}
+
+// simulate plays the configured scenario until the configuration changes
+func (r *Reader) simulate(ctx context.Context, conf config.Snapshot) {
+	scenario := DefaultScenario()
+	if conf.Escenario != "" {
+		s, err := LoadNamedScenario(conf.Escenario)
+		if err != nil {
+			log.Printf("[X] %v. Usando escenario por defecto", err)
+		} else {
+			scenario = s
+		}
+	}
+	sim := NewSimulator(scenario)
+	log.Printf("[~] Modo prueba activado - Ambiente: %s - Escenario: %s (semilla %d)",
+		conf.Ambiente, scenario.Name, sim.Seed())
+
+	lastData := time.Now()
+	for r.config.Get() == conf {
+		tick := sim.Next()
+		var ev Event
+		publish := false
+		switch {
+		case tick.Code == "" && tick.Reading == nil:
+			// A pause is a silent line: past the read timeout it stalls
+			// and reports ERR_TIMEOUT as the serial reader does
+			end := time.Now().Add(tick.Wait)
+			for {
+				deadline := lastData.Add(conf.Serial.ReadTimeout)
+				if !deadline.Before(end) {
+					break
+				}
+				if !r.sleep(ctx, time.Until(deadline)) {
+					return
+				}
+				r.setState(StateStalled, ErrTimeout)
+				failure := r.fail(conf.Puerto, ErrTimeout, errors.New("pausa del escenario"))
+				log.Printf("[~] %v [simulación]", failure)
+				lastData = time.Now()
+			}
+			tick.Wait = time.Until(end)
+		case tick.Code != "":
+			// Scripted errors are counted and de-duplicated like real ones
+			r.setState(StateStalled, tick.Code)
+			failure := r.fail(conf.Puerto, tick.Code, errors.New("paso de error del escenario"))
+			log.Printf("[!] %v [simulación]", failure)
+		case tick.Reading != nil:
+			r.setState(StateReading, "")
+			if code := RangeCode(conf.Rango, *tick.Reading); code != "" {
+				failure := r.fail(conf.Puerto, code, fmt.Errorf("peso %s", tick.Reading))
+				log.Printf("[!] %v [simulación]", failure)
+				break
+			}
+			r.clearError()
+			ev, publish = r.event(conf, *tick.Reading), true
+		}
+		if tick.Reading != nil || tick.Code != "" {
+			lastData = time.Now()
+		}
+		if publish {
+			select {
+			case <-ctx.Done():
+				return
+			case r.broadcast <- ev:
+			}
+		}
+		if !r.sleep(ctx, tick.Wait) {
+			return
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package scale
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/adcondev/scale-daemon/internal/config"
+)
+
+func weight(v float64) *float64 { return &v }
+
+func TestSimulatorPlaysSteps(t *testing.T) {
+	sim := NewSimulator(&Scenario{
+		Seed:       7,
+		IntervalMs: 100,
+		Steps: []ScenarioStep{
+			{Type: StepWeight, Weight: weight(0), DurationMs: 100},
+			{Type: StepWeight, Weight: weight(2), SettleMs: 200, DurationMs: 300},
+			{Type: StepOverload, DurationMs: 100},
+			{Type: StepWeight, Weight: weight(-0.5), DurationMs: 100},
+			{Type: StepError, Code: ErrEOF, DurationMs: 100},
+			{Type: StepPause, DurationMs: 1000},
+		},
+	})
+
+	want := []struct {
+		wire   string
+		stable bool
+		code   string
+	}{
+		{wire: "0.00", stable: true},
+		{wire: "0.00"}, // settling from 0 to 2
+		{wire: "1.00"},
+		{wire: "2.00", stable: true},
+		{wire: "2.00"}, // overload
+		{wire: "-0.50", stable: true},
+		{code: ErrEOF},
+	}
+	for i, w := range want {
+		tick := sim.Next()
+		switch {
+		case w.code != "":
+			if tick.Code != w.code {
+				t.Errorf("tick %d: code = %q, want %q", i, tick.Code, w.code)
+			}
+		case tick.Reading == nil:
+			t.Fatalf("tick %d: no reading", i)
+		case tick.Reading.String() != w.wire || tick.Reading.Stable != w.stable:
+			t.Errorf("tick %d: %s stable=%v, want %s stable=%v", i, tick.Reading, tick.Reading.Stable, w.wire, w.stable)
+		}
+		if i == 4 && !tick.Reading.OutOfRange {
+			t.Error("Expected the overload step to flag the reading out of range")
+		}
+	}
+
+	// The final pause holds: nothing is sent, one wait per step duration
+	for range 2 {
+		if tick := sim.Next(); tick.Reading != nil || tick.Code != "" || tick.Wait != time.Second {
+			t.Errorf("Pause tick = %+v", tick)
+		}
+	}
+}
+
+func TestSimulatorSeedIsDeterministic(t *testing.T) {
+	run := func(seed uint64) []string {
+		s := DefaultScenario()
+		s.Seed = seed
+		sim := NewSimulator(s)
+		var out []string
+		for len(out) < 12 {
+			if tick := sim.Next(); tick.Reading != nil {
+				out = append(out, tick.Reading.String())
+			}
+		}
+		return out
+	}
+
+	a, b := run(42), run(42)
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("Same seed diverged at %d: %v vs %v", i, a, b)
+		}
+	}
+	if c := run(43); c[0] == a[0] && c[5] == a[5] {
+		t.Errorf("Different seeds produced the same weights: %v", c)
+	}
+}
+
+func TestLoadScenario(t *testing.T) {
+	dir := useCaptureDir(t)
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	ok := write("ok.json", `{"nombre": "retiro", "semilla": 1, "unidad": "lb", "pasos": [{"tipo": "peso", "peso": 3, "duracionMs": 500}]}`)
+	s, err := LoadScenario(ok)
+	if err != nil || s.Name != "retiro" || s.Unit != "lb" || len(s.Steps) != 1 {
+		t.Errorf("LoadScenario() = %+v, %v", s, err)
+	}
+	if s, err := LoadNamedScenario("ok.json"); err != nil || s.Name != "retiro" {
+		t.Errorf("LoadNamedScenario(ok.json) = %+v, %v", s, err)
+	}
+	for _, name := range []string{ok, "../ok.json", "sub/ok.json"} {
+		if _, err := LoadNamedScenario(name); !errors.Is(err, ErrInvalidFileName) {
+			t.Errorf("LoadNamedScenario(%q) error = %v, want ErrInvalidFileName", name, err)
+		}
+	}
+
+	invalid := map[string]string{
+		"empty.json":    `{"pasos": []}`,
+		"type.json":     `{"pasos": [{"tipo": "vuelo", "duracionMs": 100}]}`,
+		"duration.json": `{"pasos": [{"tipo": "peso", "peso": 1}]}`,
+		"code.json":     `{"pasos": [{"tipo": "error", "codigo": "ERR_X", "duracionMs": 100}]}`,
+		"unit.json":     `{"unidad": "st", "pasos": [{"tipo": "pausa", "duracionMs": 100}]}`,
+		"syntax.json":   `{"pasos": [`,
+	}
+	for name, content := range invalid {
+		if _, err := LoadScenario(write(name, content)); !errors.Is(err, ErrInvalidScenario) {
+			t.Errorf("LoadScenario(%s) error = %v, want ErrInvalidScenario", name, err)
+		}
+	}
+}
+
+func TestReaderPlaysScenarioInTestMode(t *testing.T) {
+	path := filepath.Join(useCaptureDir(t), "cable.json")
+	scenario := `{"intervaloMs": 10, "pasos": [
+		{"tipo": "peso", "peso": 1.25, "duracionMs": 10},
+		{"tipo": "error", "codigo": "ERR_EOF", "duracionMs": 10}
+	]}`
+	if err := os.WriteFile(path, []byte(scenario), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.New(config.Environment{DefaultPort: "COM_TEST", DefaultMode: true})
+	cfg.UpdateScenario("cable.json")
+	broadcast := make(chan Event, 10)
+	r := NewReader(cfg, broadcast)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.Start(ctx)
+
+	for _, want := range []string{"1.25", ErrEOF} {
+		select {
+		case ev := <-broadcast:
+			if ev.String() != want {
+				t.Errorf("Published %q, want %q", ev, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out waiting for %s", want)
+		}
+	}
+
+	// The error step holds: it keeps counting but is broadcast only once
+	// per ErrorRepeatInterval, like a real port error
+	time.Sleep(100 * time.Millisecond)
+	if len(broadcast) != 0 {
+		t.Errorf("Repeated error broadcast %q", <-broadcast)
+	}
+	if n := r.ErrorCounts()[ErrEOF]; n < 2 {
+		t.Errorf("ErrorCounts()[%s] = %d, want the repetitions counted", ErrEOF, n)
+	}
+}
+
+func TestSimulatorPauseTimesOut(t *testing.T) {
+	path := filepath.Join(useCaptureDir(t), "pausa.json")
+	scenario := `{"intervaloMs": 10, "pasos": [
+		{"tipo": "peso", "peso": 1.25, "duracionMs": 10},
+		{"tipo": "pausa", "duracionMs": 60000}
+	]}`
+	if err := os.WriteFile(path, []byte(scenario), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.New(config.Environment{DefaultPort: "COM_TEST", DefaultMode: true})
+	serial := config.DefaultSerialSettings()
+	serial.ReadTimeout = 100 * time.Millisecond
+	if _, err := cfg.UpdateSerial(serial); err != nil {
+		t.Fatal(err)
+	}
+	cfg.UpdateScenario("pausa.json")
+	broadcast := make(chan Event, 10)
+	r := NewReader(cfg, broadcast)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.Start(ctx)
+
+	// The pause outlasts the read timeout, as an indicator that went silent
+	start := time.Now()
+	for _, want := range []string{"1.25", ErrTimeout} {
+		select {
+		case ev := <-broadcast:
+			if ev.String() != want {
+				t.Errorf("Published %q, want %q", ev, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out waiting for %s", want)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("ERR_TIMEOUT after %v, before the read timeout", elapsed)
+	}
+	if state, _ := r.State(); state != StateStalled {
+		t.Errorf("State() during pause = %s, want %s", state, StateStalled)
+	}
+}
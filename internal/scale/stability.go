@@ -0,0 +1,73 @@
+package scale
+
+import (
+	"math"
+
+	"github.com/adcondev/scale-daemon/internal/config"
+)
+
+// StabilityDetector decides whether the platform is settled. Readings whose
+// driver reports a stability flag are trusted as-is; otherwise the weight is
+// stable once the last Window readings stay within Tolerance divisions.
+// It is not safe for concurrent use.
+type StabilityDetector struct {
+	window    int
+	tolerance float64
+	division  float64
+	values    []float64
+}
+
+// NewStabilityDetector creates a detector comparing window readings with a
+// tolerance expressed in divisions. A zero division is derived from the
+// decimals of each reading (0.01 for "12.50").
+func NewStabilityDetector(window int, tolerance, division float64) *StabilityDetector {
+	if window < 2 {
+		window = config.DefaultStabilityWindow
+	}
+	if tolerance < 0 {
+		tolerance = config.DefaultStabilityTolerance
+	}
+	return &StabilityDetector{
+		window:    window,
+		tolerance: tolerance,
+		division:  division,
+		values:    make([]float64, 0, window),
+	}
+}
+
+// Update records a reading and returns whether it is stable
+func (d *StabilityDetector) Update(r Reading) bool {
+	if len(d.values) == d.window {
+		copy(d.values, d.values[1:])
+		d.values = d.values[:d.window-1]
+	}
+	d.values = append(d.values, r.Value)
+
+	if r.HasStability {
+		return r.Stable
+	}
+	if len(d.values) < d.window {
+		return false
+	}
+
+	lo, hi := d.values[0], d.values[0]
+	for _, v := range d.values[1:] {
+		lo = math.Min(lo, v)
+		hi = math.Max(hi, v)
+	}
+	return hi-lo <= d.tolerance*divisionFor(d.division, r)+1e-9
+}
+
+// Reset forgets previous readings, e.g. after a reconnect
+func (d *StabilityDetector) Reset() {
+	d.values = d.values[:0]
+}
+
+// divisionFor returns the configured division, or the smallest increment
+// representable with the reading's decimals.
+func divisionFor(division float64, r Reading) float64 {
+	if division > 0 {
+		return division
+	}
+	return math.Pow10(-r.Decimals)
+}
@@ -0,0 +1,80 @@
+package scale
+
+import (
+	"testing"
+
+	"github.com/adcondev/scale-daemon/internal/config"
+)
+
+func TestStabilityDetectorComputesFromWindow(t *testing.T) {
+	d := NewStabilityDetector(3, 1, 0)
+	reading := func(v float64) Reading { return Reading{Value: v, Decimals: 2} }
+
+	steps := []struct {
+		value  float64
+		stable bool
+	}{
+		{10.00, false}, // window not full yet
+		{10.50, false},
+		{10.01, false}, // spread 0.50 > 1 division
+		{10.01, false},
+		{10.02, true}, // 10.01, 10.01, 10.02 within 0.01
+		{10.02, true},
+		{12.00, false},
+	}
+	for i, s := range steps {
+		if got := d.Update(reading(s.value)); got != s.stable {
+			t.Errorf("step %d (%.2f): stable = %v, want %v", i, s.value, got, s.stable)
+		}
+	}
+
+	d.Reset()
+	if d.Update(reading(12.00)) {
+		t.Error("Expected unstable right after Reset")
+	}
+}
+
+func TestStabilityDetectorUsesDivision(t *testing.T) {
+	// 5 g division with readings reported in grams without decimals
+	d := NewStabilityDetector(2, 1, 5)
+	d.Update(Reading{Value: 100})
+	if !d.Update(Reading{Value: 105}) {
+		t.Error("Expected 5 g spread to be stable with a 5 g division")
+	}
+	if d.Update(Reading{Value: 115}) {
+		t.Error("Expected 10 g spread to be unstable with a 5 g division")
+	}
+}
+
+func TestStabilityDetectorTrustsDeviceFlag(t *testing.T) {
+	d := NewStabilityDetector(5, 1, 0)
+	if !d.Update(Reading{Value: 1, HasStability: true, Stable: true}) {
+		t.Error("Expected device stable flag to be trusted")
+	}
+	if d.Update(Reading{Value: 1, HasStability: true, Stable: false}) {
+		t.Error("Expected device motion flag to be trusted")
+	}
+}
+
+func TestReaderStableOnly(t *testing.T) {
+	ch := make(chan Event, 10)
+	r := &Reader{broadcast: ch, stability: NewStabilityDetector(2, 1, 0)}
+
+	conf := config.Snapshot{Estabilidad: config.StabilitySettings{Window: 2, Tolerance: 1, StableOnly: true}}
+	r.handleFrame(conf, rhinoDriver{}, []byte("10.00"))
+	r.handleFrame(conf, rhinoDriver{}, []byte("10.50"))
+	r.handleFrame(conf, rhinoDriver{}, []byte("10.50"))
+
+	var published []Event
+	for len(ch) > 0 {
+		if ev := <-ch; !ev.Suppressed {
+			published = append(published, ev)
+		}
+	}
+	if len(published) != 1 {
+		t.Fatalf("Published %v, want only the settled reading", published)
+	}
+	if ev := published[0]; ev.String() != "10.50" || !ev.Reading.Stable {
+		t.Errorf("Published %+v, want stable 10.50", ev)
+	}
+}
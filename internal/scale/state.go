@@ -0,0 +1,95 @@
+package scale
+
+import (
+	"log"
+	"time"
+)
+
+// State is the connection state of a Reader
+type State string
+
+// Reader states
+const (
+	// StateDisconnected: the port is closed and no connection is scheduled,
+	// e.g. while the reader is paused for a port probe.
+	StateDisconnected State = "disconnected"
+	// StateConnecting: the port is being opened.
+	StateConnecting State = "connecting"
+	// StateConnected: the port is open and no frame has arrived yet.
+	StateConnected State = "connected"
+	// StateReading: the indicator is sending frames.
+	StateReading State = "reading"
+	// StateStalled: the port is open but the indicator stopped answering
+	// or the cable was pulled.
+	StateStalled State = "stalled"
+	// StateBackoff: the port is closed and the reader waits to reconnect.
+	StateBackoff State = "backoff"
+	// StateStopped: the reader has exited.
+	StateStopped State = "stopped"
+)
+
+// Transition is a change of Reader state
+type Transition struct {
+	From State
+	To   State
+	At   time.Time
+	// Cause is the error code that led to the new state, if any.
+	Cause string
+}
+
+// State returns the current state and when the reader entered it
+func (r *Reader) State() (State, time.Time) {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+	if r.state == "" {
+		return StateDisconnected, r.stateSince
+	}
+	return r.state, r.stateSince
+}
+
+// Subscribe returns a channel receiving every state transition and a
+// function that cancels the subscription. Transitions are dropped while
+// the channel buffer is full.
+func (r *Reader) Subscribe(buffer int) (<-chan Transition, func()) {
+	ch := make(chan Transition, buffer)
+	r.stateMu.Lock()
+	if r.subscribers == nil {
+		r.subscribers = make(map[chan Transition]struct{})
+	}
+	r.subscribers[ch] = struct{}{}
+	r.stateMu.Unlock()
+
+	return ch, func() {
+		r.stateMu.Lock()
+		delete(r.subscribers, ch)
+		r.stateMu.Unlock()
+	}
+}
+
+// setState moves the reader to state and notifies subscribers. Setting the
+// current state again does nothing.
+func (r *Reader) setState(state State, cause string) {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+	from := r.state
+	if from == "" {
+		from = StateDisconnected
+	}
+	if from == state {
+		return
+	}
+	t := Transition{From: from, To: state, At: time.Now(), Cause: cause}
+	r.state, r.stateSince = state, t.At
+	if cause != "" {
+		log.Printf("[i] Estado del lector: %s → %s (%s)", from, state, cause)
+	} else {
+		log.Printf("[i] Estado del lector: %s → %s", from, state)
+	}
+	for ch := range r.subscribers {
+		select {
+		case ch <- t:
+		default:
+			// Subscriber is behind, skip
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package scale
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"go.bug.st/serial"
+
+	"github.com/adcondev/scale-daemon/internal/config"
+)
+
+func TestReaderStateTransitions(t *testing.T) {
+	origSerialOpen := serialOpen
+	defer func() { serialOpen = origSerialOpen }()
+
+	port := &scriptedPort{replies: map[string]string{"SI\r\n": "S S      1.000 kg\r\n"}}
+	opens := 0
+	serialOpen = func(_ string, _ *serial.Mode) (Port, error) {
+		opens++
+		if opens == 1 {
+			return nil, errors.New("puerto ausente")
+		}
+		return port, nil
+	}
+
+	cfg := config.New(config.Environment{DefaultPort: "COM_TEST"})
+	cfg.Update("", "MT-SICS", false)
+	retry := config.DefaultRetrySettings()
+	retry.Initial, retry.Jitter = 100*time.Millisecond, 0
+	if _, err := cfg.UpdateRetry(retry); err != nil {
+		t.Fatal(err)
+	}
+	r := NewReader(cfg, make(chan Event, 100))
+	if state, since := r.State(); state != StateDisconnected || since.IsZero() {
+		t.Errorf("Initial State() = %s, %v", state, since)
+	}
+	states, unsubscribe := r.Subscribe(20)
+	defer unsubscribe()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		r.Start(ctx)
+		close(done)
+	}()
+
+	var got []string
+	next := func(want State) {
+		t.Helper()
+		for {
+			select {
+			case tr := <-states:
+				got = append(got, fmt.Sprintf("%s>%s:%s", tr.From, tr.To, tr.Cause))
+				if tr.To == want {
+					return
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatalf("Timed out waiting for %s, got %v", want, got)
+			}
+		}
+	}
+	next(StateReading)
+
+	// The indicator goes silent
+	port.mu.Lock()
+	port.replies = nil
+	port.mu.Unlock()
+	next(StateStalled)
+
+	cancel()
+	<-done
+	next(StateStopped)
+
+	want := []string{
+		"disconnected>connecting:",
+		"connecting>backoff:ERR_SCALE_CONN",
+		"backoff>connecting:",
+		"connecting>connected:",
+		"connected>reading:",
+		"reading>stalled:ERR_TIMEOUT",
+	}
+	if fmt.Sprint(got[:len(want)]) != fmt.Sprint(want) {
+		t.Errorf("Transitions = %v, want %v", got, want)
+	}
+	if state, since := r.State(); state != StateStopped || time.Since(since) > time.Second {
+		t.Errorf("Final State() = %s, %v", state, since)
+	}
+}
+
+func TestReaderPauseSkipsBackoff(t *testing.T) {
+	origSerialOpen := serialOpen
+	defer func() { serialOpen = origSerialOpen }()
+	serialOpen = func(_ string, _ *serial.Mode) (Port, error) {
+		return &scriptedPort{replies: map[string]string{"SI\r\n": "S S      1.000 kg\r\n"}}, nil
+	}
+
+	cfg := config.New(config.Environment{DefaultPort: "COM_TEST"})
+	cfg.Update("", "MT-SICS", false)
+	r := NewReader(cfg, make(chan Event, 100))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.Start(ctx)
+
+	waitState := func(want State) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for state, _ := r.State(); state != want; state, _ = r.State() {
+			if time.Now().After(deadline) {
+				t.Fatalf("State() = %s, want %s", state, want)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	waitState(StateReading)
+
+	// A port probe pauses the reader: the port closes without a retry
+	r.Pause()
+	time.Sleep(3 * PollInterval)
+	if state, _ := r.State(); state != StateDisconnected {
+		t.Errorf("State() while paused = %s, want %s", state, StateDisconnected)
+	}
+	if retry := r.RetryStatus(); retry.Attempts != 0 || !retry.NextAttempt.IsZero() {
+		t.Errorf("RetryStatus() while paused = %+v, want no retry", retry)
+	}
+
+	r.Resume()
+	waitState(StateReading)
+}
@@ -0,0 +1,126 @@
+package scale
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+)
+
+// Errors returned by software tare operations
+var (
+	// ErrNoReading is returned when a tare is requested before any weight
+	// has been read.
+	ErrNoReading = errors.New("no hay lectura de peso para tarar")
+	// ErrInMotion is returned when a tare is requested while the platform
+	// is not stable.
+	ErrInMotion = errors.New("peso en movimiento")
+	// ErrInvalidTare is returned for negative preset tares.
+	ErrInvalidTare = errors.New("tara inválida")
+)
+
+// softwareTare is a tare kept by the reader for indicators that cannot
+// store one themselves. It is subtracted from gross readings.
+type softwareTare struct {
+	active bool
+	value  float64
+	unit   string
+}
+
+func isTareOperation(op Operation) bool {
+	return op == OpTare || op == OpClearTare || op == OpPresetTare
+}
+
+// softwareOperation performs a tare operation in the reader
+func (r *Reader) softwareOperation(op Operation, params OperationParams) (OperationResult, error) {
+	res := OperationResult{Op: op, Software: true}
+	last := r.lastReading()
+
+	switch op {
+	case OpTare:
+		if last.Time.IsZero() {
+			return res, ErrNoReading
+		}
+		if !last.Stable {
+			return res, ErrInMotion
+		}
+		r.setSoftwareTare(softwareTare{active: true, value: last.Value, unit: last.Unit})
+		res.Value, res.Unit = last.Value, last.Unit
+
+	case OpClearTare:
+		r.setSoftwareTare(softwareTare{})
+
+	case OpPresetTare:
+		if params.Value < 0 || math.IsNaN(params.Value) || math.IsInf(params.Value, 0) {
+			return res, fmt.Errorf("%w: %v", ErrInvalidTare, params.Value)
+		}
+		unit := params.Unit
+		if unit == "" {
+			unit = last.Unit
+		}
+		r.setSoftwareTare(softwareTare{active: params.Value > 0, value: params.Value, unit: unit})
+		res.Value, res.Unit = params.Value, unit
+
+	default:
+		return res, fmt.Errorf("%w: %s", ErrUnsupportedOperation, op)
+	}
+
+	log.Printf("[i] Tara por software %s: %.4g %s", op, res.Value, res.Unit)
+	return res, nil
+}
+
+func (r *Reader) setSoftwareTare(t softwareTare) {
+	r.tareMu.Lock()
+	r.softTare = t
+	r.tareMu.Unlock()
+}
+
+func (r *Reader) lastReading() Reading {
+	r.tareMu.Lock()
+	defer r.tareMu.Unlock()
+	return r.last
+}
+
+// currentUnit returns the unit of the last reading, gross or net
+func (r *Reader) currentUnit() string {
+	r.tareMu.Lock()
+	defer r.tareMu.Unlock()
+	return r.lastUnit
+}
+
+// Unit returns the unit of the last reading, or the configured unidad
+// before the first one. Empty means the unit is unknown and weights cannot
+// be converted.
+func (r *Reader) Unit() string {
+	if unit := r.currentUnit(); unit != "" {
+		return unit
+	}
+	return r.config.Get().Unidad
+}
+
+// applySoftwareTare records a gross reading and subtracts the software
+// tare from it. Net readings from indicators with an active hardware tare
+// and readings in a different unit are left untouched.
+func (r *Reader) applySoftwareTare(reading Reading) Reading {
+	r.tareMu.Lock()
+	defer r.tareMu.Unlock()
+
+	// Indicators such as MT-SICS only report net weights
+	if reading.Unit != "" {
+		r.lastUnit = reading.Unit
+	}
+	if reading.Mode != ModeGross && reading.Mode != "" {
+		return reading
+	}
+	r.last = reading
+
+	t := r.softTare
+	if !t.active || (t.unit != "" && reading.Unit != "" && t.unit != reading.Unit) {
+		return reading
+	}
+	factor := math.Pow10(reading.Decimals)
+	reading.Value = math.Round((reading.Value-t.value)*factor) / factor
+	reading.Tare = t.value
+	reading.Mode = ModeNet
+	return reading
+}
@@ -0,0 +1,61 @@
+package scale
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/adcondev/scale-daemon/internal/config"
+)
+
+func TestSoftwareTare(t *testing.T) {
+	cfg := config.New(config.Environment{DefaultPort: "COM_TEST"})
+	ch := make(chan Event, 10)
+	r := NewReader(cfg, ch)
+	r.stability = NewStabilityDetector(2, 1, 0)
+	conf := cfg.Get()
+	ctx := context.Background()
+
+	if _, err := r.Execute(ctx, OpTare, OperationParams{}); !errors.Is(err, ErrNoReading) {
+		t.Errorf("Tare without reading error = %v, want ErrNoReading", err)
+	}
+
+	r.handleFrame(conf, rhinoDriver{}, []byte("1.50"))
+	if _, err := r.Execute(ctx, OpTare, OperationParams{}); !errors.Is(err, ErrInMotion) {
+		t.Errorf("Tare while settling error = %v, want ErrInMotion", err)
+	}
+
+	r.handleFrame(conf, rhinoDriver{}, []byte("1.50"))
+	res, err := r.Execute(ctx, OpTare, OperationParams{})
+	if err != nil || !res.Software || res.Value != 1.5 {
+		t.Fatalf("Execute(tare) = %+v, %v", res, err)
+	}
+
+	r.handleFrame(conf, rhinoDriver{}, []byte("3.75"))
+	var ev Event
+	for len(ch) > 0 {
+		ev = <-ch
+	}
+	if ev.String() != "2.25" || ev.Reading.Mode != ModeNet || ev.Reading.Tare != 1.5 {
+		t.Errorf("Net reading = %+v, want 2.25 net with 1.50 tare", ev.Reading)
+	}
+
+	if _, err := r.Execute(ctx, OpPresetTare, OperationParams{Value: -1}); !errors.Is(err, ErrInvalidTare) {
+		t.Errorf("Negative preset tare error = %v, want ErrInvalidTare", err)
+	}
+	if _, err := r.Execute(ctx, OpPresetTare, OperationParams{Value: 0.25}); err != nil {
+		t.Fatalf("Execute(presetTare) error: %v", err)
+	}
+	r.handleFrame(conf, rhinoDriver{}, []byte("3.75"))
+	if ev = <-ch; ev.String() != "3.50" {
+		t.Errorf("Reading with preset tare = %s, want 3.50", ev)
+	}
+
+	if _, err := r.Execute(ctx, OpClearTare, OperationParams{}); err != nil {
+		t.Fatalf("Execute(clearTare) error: %v", err)
+	}
+	r.handleFrame(conf, rhinoDriver{}, []byte("3.75"))
+	if ev = <-ch; ev.String() != "3.75" || ev.Reading.Mode != ModeGross {
+		t.Errorf("Reading after clearing tare = %+v, want gross 3.75", ev.Reading)
+	}
+}
@@ -0,0 +1,132 @@
+package scale
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adcondev/scale-daemon/internal/config"
+)
+
+// TCPScheme prefixes a puerto value naming a raw TCP socket, such as a
+// serial-to-Ethernet converter: "tcp://192.168.1.50:4001"
+const TCPScheme = "tcp://"
+
+// tcpTimeout bounds connecting and writing to a network indicator
+const tcpTimeout = 5 * time.Second
+
+// errRemoteClosed is returned when a network indicator closes the
+// connection. Unlike io.EOF on a serial port, the socket cannot recover,
+// so the reader treats it as a read error and reconnects.
+var errRemoteClosed = errors.New("el equipo remoto cerró la conexión")
+
+// IsNetworkPort reports whether a puerto value names a network address
+// (tcp:// or rfc2217://) rather than a local serial device
+func IsNetworkPort(puerto string) bool {
+	lower := strings.ToLower(puerto)
+	return strings.HasPrefix(lower, TCPScheme) || strings.HasPrefix(lower, RFC2217Scheme)
+}
+
+// hasLineSettings reports whether the configured line settings reach the
+// device; raw TCP sockets leave them to the converter
+func hasLineSettings(puerto string) bool {
+	return !strings.HasPrefix(strings.ToLower(puerto), TCPScheme)
+}
+
+// networkAddress extracts and validates host:port from a network puerto value
+func networkAddress(puerto string) (string, error) {
+	u, err := url.Parse(puerto)
+	if err != nil {
+		return "", fmt.Errorf("dirección de red inválida %q: %w", puerto, err)
+	}
+	if u.Host == "" || u.Port() == "" || (u.Path != "" && u.Path != "/") {
+		return "", fmt.Errorf("dirección de red inválida %q: se espera %shost:puerto", puerto, u.Scheme+"://")
+	}
+	return u.Host, nil
+}
+
+// openPort opens a serial device or, for network values, a connection to
+// it. Line settings are ignored for tcp://, where the converter keeps its
+// own serial configuration, and sent to the device server for rfc2217://.
+// replay:// values play back a capture file.
+func openPort(puerto string, line config.SerialSettings) (Port, error) {
+	if IsReplayPort(puerto) {
+		return replayOpen(puerto)
+	}
+	if !IsNetworkPort(puerto) {
+		return serialOpen(puerto, serialMode(line))
+	}
+	addr, err := networkAddress(puerto)
+	if err != nil {
+		return nil, err
+	}
+	if hasLineSettings(puerto) {
+		return rfc2217Open(addr, line)
+	}
+	return tcpOpen(addr)
+}
+
+// variable to allow mocking network connections
+var tcpOpen = func(addr string) (Port, error) {
+	conn, err := net.DialTimeout("tcp", addr, tcpTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return &tcpPort{conn: conn}, nil
+}
+
+// tcpPort adapts a network connection to Port. Reads behave like a serial
+// port: when the read timeout elapses without data, Read returns 0, nil.
+type tcpPort struct {
+	conn net.Conn
+
+	mu      sync.Mutex
+	timeout time.Duration
+}
+
+func (p *tcpPort) SetReadTimeout(t time.Duration) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.timeout = t
+	return nil
+}
+
+func (p *tcpPort) Read(b []byte) (int, error) {
+	p.mu.Lock()
+	timeout := p.timeout
+	p.mu.Unlock()
+
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	if err := p.conn.SetReadDeadline(deadline); err != nil {
+		return 0, err
+	}
+
+	n, err := p.conn.Read(b)
+	switch {
+	case errors.Is(err, os.ErrDeadlineExceeded):
+		return n, nil
+	case errors.Is(err, io.EOF):
+		return n, errRemoteClosed
+	}
+	return n, err
+}
+
+func (p *tcpPort) Write(b []byte) (int, error) {
+	if err := p.conn.SetWriteDeadline(time.Now().Add(tcpTimeout)); err != nil {
+		return 0, err
+	}
+	return p.conn.Write(b)
+}
+
+func (p *tcpPort) Close() error {
+	return p.conn.Close()
+}
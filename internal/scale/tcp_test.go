@@ -0,0 +1,85 @@
+package scale
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/adcondev/scale-daemon/internal/config"
+)
+
+func TestOpenPortTCPAddress(t *testing.T) {
+	for _, puerto := range []string{"tcp://", "tcp://10.0.0.5", "tcp://10.0.0.5:4001/x", "tcp://[::1"} {
+		if _, err := openPort(puerto, config.DefaultSerialSettings()); err == nil {
+			t.Errorf("openPort(%q) expected an address error", puerto)
+		}
+	}
+	if !IsNetworkPort("TCP://10.0.0.5:4001") || IsNetworkPort("COM3") {
+		t.Error("IsNetworkPort() misclassified a puerto value")
+	}
+}
+
+func TestTCPPortBehavesLikeSerial(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = ln.Close() }()
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err == nil {
+			accepted <- conn
+		}
+	}()
+
+	port, err := openPort("tcp://"+ln.Addr().String(), config.DefaultSerialSettings())
+	if err != nil {
+		t.Fatalf("openPort() error: %v", err)
+	}
+	defer func() { _ = port.Close() }()
+	remote := <-accepted
+
+	// No data before the read timeout: 0, nil like a serial port
+	_ = port.SetReadTimeout(20 * time.Millisecond)
+	buf := make([]byte, 16)
+	if n, err := port.Read(buf); n != 0 || err != nil {
+		t.Errorf("Read() on idle socket = %d, %v, want 0, nil", n, err)
+	}
+
+	if _, err := port.Write([]byte("P")); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+	if n, _ := remote.Read(buf); string(buf[:n]) != "P" {
+		t.Errorf("Remote received %q, want the poll command", buf[:n])
+	}
+	_, _ = remote.Write([]byte("1.50\r\n"))
+	if n, err := port.Read(buf); err != nil || string(buf[:n]) != "1.50\r\n" {
+		t.Errorf("Read() = %q, %v", buf[:n], err)
+	}
+
+	// A closed connection is a read error so the reader reconnects
+	_ = remote.Close()
+	if _, err := port.Read(buf); !errors.Is(err, errRemoteClosed) {
+		t.Errorf("Read() after remote close error = %v, want errRemoteClosed", err)
+	}
+}
+
+func TestReaderReconnectsAfterRemoteClose(t *testing.T) {
+	cfg := config.New(config.Environment{DefaultPort: "tcp://127.0.0.1:1"})
+	r := NewReader(cfg, make(chan Event, 10))
+	r.port = newStreamPort()
+
+	// Canceled so the retry delay does not slow the test
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if !r.handleReadError(ctx, cfg.Get(), errRemoteClosed) {
+		t.Fatal("handleReadError() should keep the read loop running")
+	}
+	if r.currentPort() != nil {
+		t.Error("Expected the socket to be closed so the next cycle reconnects")
+	}
+}
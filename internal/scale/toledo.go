@@ -0,0 +1,165 @@
+package scale
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Toledo status word bits
+const (
+	// Status word A: bits 0-2 select the decimal point position.
+	toledoSWADecimalMask = 0x07
+
+	// Status word B
+	toledoSWBNet        = 1 << 0
+	toledoSWBNegative   = 1 << 1
+	toledoSWBOutOfRange = 1 << 2
+	toledoSWBMotion     = 1 << 3
+	toledoSWBKilograms  = 1 << 4
+
+	// Status word C: bits 0-2 select an alternate unit.
+	toledoSWCUnitMask = 0x07
+)
+
+// toledoFrameLen is STX + 3 status bytes + 6 weight digits + 6 tare digits
+const toledoFrameLen = 16
+
+func init() {
+	RegisterDriver(toledoDriver{}, "toledo", "mettler toledo", "toledo continuo")
+}
+
+// toledoDriver decodes the Mettler Toledo standard continuous output
+// (8142, Panther, Jaguar, etc.):
+//
+//	STX SWA SWB SWC WWWWWW TTTTTT CR [CKS]
+//
+// The indicator streams frames without being polled, so Command is empty.
+// The checksum byte is only sent when enabled in the indicator setup; it
+// is verified when present.
+type toledoDriver struct{}
+
+func (toledoDriver) Name() string { return "Toledo 8142" }
+
+func (toledoDriver) Command() []byte { return nil }
+
+func (toledoDriver) Framing() Framing {
+	return Framing{
+		Start:            []byte{stx},
+		Terminator:       []byte{cr},
+		Checksum:         SumChecksum7,
+		OptionalChecksum: true,
+	}
+}
+
+func (toledoDriver) Parse(frame []byte) (Reading, error) {
+	r := Reading{Raw: append([]byte(nil), frame...)}
+
+	if len(frame) < toledoFrameLen || frame[0] != stx {
+		return r, fmt.Errorf("%w: trama Toledo de %d bytes", ErrUnparsable, len(frame))
+	}
+	swa, swb, swc := frame[1], frame[2], frame[3]
+
+	// Decimal positions 0-2 scale the reading up (x100, x10, x1); 3-7 place
+	// a decimal point 1-5 digits from the right.
+	pos := int(swa & toledoSWADecimalMask)
+	decimals := pos - 2
+	multiplier := 1.0
+	if decimals < 0 {
+		multiplier = math.Pow10(-decimals)
+		decimals = 0
+	}
+
+	weight, err := toledoDigits(frame[4:10])
+	if err != nil {
+		return r, err
+	}
+	tare, err := toledoDigits(frame[10:16])
+	if err != nil {
+		return r, err
+	}
+	scaleDiv := math.Pow10(decimals)
+
+	r.Value = float64(weight) * multiplier / scaleDiv
+	r.Tare = float64(tare) * multiplier / scaleDiv
+	r.Decimals = decimals
+	if swb&toledoSWBNegative != 0 {
+		r.Value = -r.Value
+	}
+	r.Stable = swb&toledoSWBMotion == 0
+	r.HasStability = true
+	r.OutOfRange = swb&toledoSWBOutOfRange != 0
+	r.Mode = ModeGross
+	if swb&toledoSWBNet != 0 {
+		r.Mode = ModeNet
+	}
+	r.Unit = toledoUnit(swb, swc)
+
+	return r, nil
+}
+
+// toledoDigits parses a fixed-width weight field padded with blanks or zeros
+func toledoDigits(field []byte) (int64, error) {
+	s := strings.TrimSpace(string(field))
+	if s == "" {
+		return 0, nil
+	}
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("%w: campo de peso Toledo %q", ErrUnparsable, field)
+	}
+	return v, nil
+}
+
+// toledoUnit resolves the display unit from status words B and C
+func toledoUnit(swb, swc byte) string {
+	switch swc & toledoSWCUnitMask {
+	case 1:
+		return "g"
+	case 3:
+		return "oz"
+	}
+	if swb&toledoSWBKilograms != 0 {
+		return "kg"
+	}
+	return "lb"
+}
+
+// Encode builds a frame with the checksum byte. Readings with more than
+// five decimals are rounded to five.
+func (toledoDriver) Encode(r Reading) []byte {
+	decimals := min(max(r.Decimals, 0), 5)
+	// SWA: bit 5 always set, bit 3 selects the x1 increment
+	swa := byte(0x28 | (decimals + 2))
+	swb := byte(0x20)
+	if r.Mode == ModeNet {
+		swb |= toledoSWBNet
+	}
+	if r.Negative() {
+		swb |= toledoSWBNegative
+	}
+	if r.OutOfRange {
+		swb |= toledoSWBOutOfRange
+	}
+	if !r.Stable {
+		swb |= toledoSWBMotion
+	}
+	swc := byte(0x20)
+	switch r.Unit {
+	case UnitGram:
+		swc |= 1
+	case UnitOunce:
+		swc |= 3
+	case UnitPound:
+	default:
+		swb |= toledoSWBKilograms
+	}
+
+	scaleDiv := math.Pow10(decimals)
+	frame := fmt.Appendf([]byte{stx, swa, swb, swc}, "%06d%06d",
+		min(int64(math.Round(math.Abs(r.Value)*scaleDiv)), 999999),
+		min(int64(math.Round(math.Abs(r.Tare)*scaleDiv)), 999999))
+	frame = append(frame, cr)
+	return append(frame, SumChecksum7(frame))
+}
@@ -0,0 +1,124 @@
+package scale
+
+import (
+	"errors"
+	"testing"
+)
+
+// toledoFrame builds a continuous output frame without checksum
+func toledoFrame(swa, swb, swc byte, weight, tare string) []byte {
+	f := []byte{stx, swa, swb, swc}
+	f = append(f, weight...)
+	f = append(f, tare...)
+	return f
+}
+
+func TestToledoParse(t *testing.T) {
+	d, err := LookupDriver("toledo")
+	if err != nil {
+		t.Fatalf("Toledo driver not registered: %v", err)
+	}
+	if len(d.Command()) != 0 {
+		t.Errorf("Toledo driver should not poll, got command %q", d.Command())
+	}
+
+	tests := []struct {
+		name       string
+		frame      []byte
+		value      float64
+		tare       float64
+		wire       string
+		unit       string
+		stable     bool
+		mode       Mode
+		outOfRange bool
+	}{
+		{"stable gross kg", toledoFrame(0x2C, 0x30, 0x20, "001250", "000000"), 12.50, 0, "12.50", "kg", true, ModeGross, false},
+		{"net in motion", toledoFrame(0x2C, 0x39, 0x20, "000500", "000150"), 5.00, 1.50, "5.00", "kg", false, ModeNet, false},
+		{"negative lb", toledoFrame(0x2B, 0x22, 0x20, "   125", "     0"), -12.5, 0, "-12.5", "lb", true, ModeGross, false},
+		{"out of range", toledoFrame(0x2C, 0x34, 0x20, "999999", "000000"), 9999.99, 0, "9999.99", "kg", true, ModeGross, true},
+		{"tens multiplier", toledoFrame(0x29, 0x30, 0x20, "000123", "000000"), 1230, 0, "1230", "kg", true, ModeGross, false},
+		{"grams", toledoFrame(0x2A, 0x30, 0x21, "000450", "000000"), 450, 0, "450", "g", true, ModeGross, false},
+	}
+
+	for _, tt := range tests {
+		r, err := d.Parse(tt.frame)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+		if r.Value != tt.value || r.Tare != tt.tare || r.Unit != tt.unit ||
+			r.Stable != tt.stable || r.Mode != tt.mode || r.OutOfRange != tt.outOfRange {
+			t.Errorf("%s: got %+v", tt.name, r)
+		}
+		if r.String() != tt.wire {
+			t.Errorf("%s: String() = %q, want %q", tt.name, r.String(), tt.wire)
+		}
+	}
+}
+
+func TestToledoParseRejectsBadFrames(t *testing.T) {
+	d := toledoDriver{}
+	for _, frame := range [][]byte{
+		nil,
+		[]byte("12.50"),
+		toledoFrame(0x2C, 0x30, 0x20, "00125", ""),
+		toledoFrame(0x2C, 0x30, 0x20, "0012X0", "000000"),
+	} {
+		if _, err := d.Parse(frame); !errors.Is(err, ErrUnparsable) {
+			t.Errorf("Parse(%q) error = %v, want ErrUnparsable", frame, err)
+		}
+	}
+}
+
+func TestToledoFramingWithChecksum(t *testing.T) {
+	f := NewFramer(toledoDriver{}.Framing())
+	first := toledoFrame(0x2C, 0x30, 0x20, "001250", "000000")
+	second := toledoFrame(0x2C, 0x30, 0x20, "001300", "000000")
+
+	withChecksum := func(frame []byte) []byte {
+		raw := append(append([]byte(nil), frame...), cr)
+		return append(raw, SumChecksum7(raw))
+	}
+
+	// Tail of a previous frame, two checksummed frames and the head of another
+	data := append([]byte("000000\r"), withChecksum(first)...)
+	data = append(data, withChecksum(second)...)
+	data = append(data, second[:5]...)
+
+	frames, garbled := f.Feed(data)
+	if garbled != 0 {
+		t.Errorf("Feed() garbled = %d, want 0", garbled)
+	}
+	if len(frames) != 2 || string(frames[0]) != string(first) || string(frames[1]) != string(second) {
+		t.Fatalf("Feed() frames = %q", frames)
+	}
+
+	// Corrupt checksum
+	bad := withChecksum(first)
+	bad[len(bad)-1] ^= 0x01
+	f.Reset()
+	frames, garbled = f.Feed(bad)
+	if garbled != 1 || len(frames) != 0 {
+		t.Errorf("Feed() with bad checksum = %q, garbled %d", frames, garbled)
+	}
+}
+
+func TestToledoFramingWithoutChecksum(t *testing.T) {
+	f := NewFramer(toledoDriver{}.Framing())
+	frame := toledoFrame(0x2C, 0x30, 0x20, "001250", "000000")
+
+	data := append(append([]byte(nil), frame...), cr)
+	data = append(data, frame...)
+	data = append(data, cr)
+
+	// The first frame is confirmed by the next STX; the last waits for more bytes
+	frames, garbled := f.Feed(data)
+	if garbled != 0 || len(frames) != 1 || string(frames[0]) != string(frame) {
+		t.Errorf("Feed() = %q, garbled %d", frames, garbled)
+	}
+	frames, _ = f.Feed([]byte{stx})
+	if len(frames) != 1 {
+		t.Errorf("Expected pending frame to complete on next STX, got %q", frames)
+	}
+}
@@ -0,0 +1,142 @@
+package scale
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// torreyImpliedDecimals is the decimal convention Torrey indicators use when
+// the weight field carries no decimal point: the value is sent in the
+// smallest display increment of the active unit.
+var torreyImpliedDecimals = map[string]int{
+	"kg": 3,
+	"lb": 2,
+	"oz": 1,
+	"g":  0,
+}
+
+func init() {
+	RegisterDriver(torreyDriver{}, "torrey l-eq", "torrey eqm", "torrey pcr", "torrey crs")
+}
+
+// torreyDriver implements the Torrey POS protocol (L-EQ, EQM, PCR, CRS).
+// The indicator is polled with "P" and answers with a frame such as
+//
+//	STX + 001250 S kg CR   -> 1.250 kg, stable
+//	STX -  1,250 M kg CR   -> -1.250 kg, in motion
+//
+// The sign is always present, the status letter is S (stable), M (motion)
+// or O (overload) and the weight may carry a decimal point, a decimal comma
+// or neither, in which case the decimals are implied by the unit.
+type torreyDriver struct{}
+
+func (torreyDriver) Name() string { return "Torrey" }
+
+func (torreyDriver) Command() []byte { return []byte("P") }
+
+func (torreyDriver) Framing() Framing {
+	return Framing{Terminator: []byte{cr}, FlushOnIdle: true}
+}
+
+func (torreyDriver) Parse(frame []byte) (Reading, error) {
+	r := Reading{
+		Raw:  append([]byte(nil), frame...),
+		Mode: ModeGross,
+	}
+
+	body := strings.TrimSpace(string(bytes.Trim(frame, "\x02\x03\r\n")))
+	if body == "" {
+		return r, fmt.Errorf("%w: trama Torrey vacía", ErrUnparsable)
+	}
+
+	negative := false
+	switch body[0] {
+	case '-':
+		negative = true
+		body = body[1:]
+	case '+':
+		body = body[1:]
+	}
+	body = strings.TrimSpace(body)
+
+	end := strings.IndexFunc(body, func(c rune) bool {
+		return (c < '0' || c > '9') && c != '.' && c != ','
+	})
+	if end < 0 {
+		end = len(body)
+	}
+	digits, rest := body[:end], strings.Fields(strings.ToLower(body[end:]))
+	if digits == "" {
+		return r, fmt.Errorf("%w: trama Torrey sin peso %q", ErrUnparsable, frame)
+	}
+
+	for _, tok := range rest {
+		switch tok {
+		case "s":
+			r.Stable, r.HasStability = true, true
+		case "m":
+			r.Stable, r.HasStability = false, true
+		case "o":
+			r.OutOfRange = true
+		case "n":
+			r.Mode = ModeNet
+		default:
+			if u := scanUnit(tok); u != "" {
+				r.Unit = u
+			}
+		}
+	}
+	if r.Unit == "" {
+		r.Unit = "kg"
+	}
+
+	digits = strings.ReplaceAll(digits, ",", ".")
+	if i := strings.IndexByte(digits, '.'); i >= 0 {
+		r.Decimals = len(digits) - i - 1
+	} else {
+		r.Decimals = torreyImpliedDecimals[r.Unit]
+		if len(digits) <= r.Decimals {
+			digits = strings.Repeat("0", r.Decimals-len(digits)+1) + digits
+		}
+		if r.Decimals > 0 {
+			digits = digits[:len(digits)-r.Decimals] + "." + digits[len(digits)-r.Decimals:]
+		}
+	}
+
+	value, err := strconv.ParseFloat(digits, 64)
+	if err != nil {
+		return r, fmt.Errorf("%w: peso Torrey %q", ErrUnparsable, digits)
+	}
+	if negative {
+		value = -value
+	}
+	r.Value = value
+	return r, nil
+}
+
+func (torreyDriver) Encode(r Reading) []byte {
+	sign := '+'
+	if r.Negative() {
+		sign = '-'
+	}
+	status := "S"
+	switch {
+	case r.OutOfRange:
+		status = "O"
+	case !r.Stable:
+		status = "M"
+	}
+	unit := r.Unit
+	if unit == "" {
+		unit = UnitKilogram
+	}
+	weight := strconv.FormatFloat(math.Abs(r.Value), 'f', r.Decimals, 64)
+	frame := fmt.Sprintf("%c%c%8s %s", stx, sign, weight, status)
+	if r.Mode == ModeNet {
+		frame += " N"
+	}
+	return append([]byte(frame+" "+unit), cr)
+}
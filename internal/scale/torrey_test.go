@@ -0,0 +1,52 @@
+package scale
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTorreyParse(t *testing.T) {
+	d, err := LookupDriver("torrey")
+	if err != nil {
+		t.Fatalf("Torrey driver not registered: %v", err)
+	}
+
+	tests := []struct {
+		in         string
+		value      float64
+		wire       string
+		unit       string
+		stable     bool
+		outOfRange bool
+	}{
+		{"\x02+ 001250 S kg", 1.25, "1.250", "kg", true, false},
+		{"\x02-  1,250 M kg", -1.25, "-1.250", "kg", false, false},
+		{"+ 12.5 S lb", 12.5, "12.5", "lb", true, false},
+		{"+001250 S lb", 12.5, "12.50", "lb", true, false},
+		{"+     5 S", 0.005, "0.005", "kg", true, false},
+		{"+ 999999 O kg", 999.999, "999.999", "kg", false, true},
+	}
+
+	for _, tt := range tests {
+		r, err := d.Parse([]byte(tt.in))
+		if err != nil {
+			t.Errorf("Parse(%q) returned error: %v", tt.in, err)
+			continue
+		}
+		if r.Value != tt.value || r.Unit != tt.unit || r.Stable != tt.stable || r.OutOfRange != tt.outOfRange {
+			t.Errorf("Parse(%q) = %+v", tt.in, r)
+		}
+		if r.String() != tt.wire {
+			t.Errorf("Parse(%q).String() = %q, want %q", tt.in, r.String(), tt.wire)
+		}
+	}
+}
+
+func TestTorreyParseRejectsNoise(t *testing.T) {
+	d := torreyDriver{}
+	for _, in := range []string{"", "\x02\x03", "+ S kg", "+ 1.2.3 S kg"} {
+		if _, err := d.Parse([]byte(in)); !errors.Is(err, ErrUnparsable) {
+			t.Errorf("Parse(%q) error = %v, want ErrUnparsable", in, err)
+		}
+	}
+}
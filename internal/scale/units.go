@@ -0,0 +1,100 @@
+package scale
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Weight units reported by indicators and accepted for conversion
+const (
+	UnitKilogram = "kg"
+	UnitGram     = "g"
+	UnitPound    = "lb"
+	UnitOunce    = "oz"
+)
+
+// gramsPer holds the exact size of each unit in grams
+var gramsPer = map[string]float64{
+	UnitKilogram: 1000,
+	UnitGram:     1,
+	UnitPound:    453.59237,
+	UnitOunce:    28.349523125,
+}
+
+// ErrUnknownUnit is returned when converting from or to an unsupported unit
+var ErrUnknownUnit = errors.New("unidad de peso desconocida")
+
+// ParseUnit normalizes a unit name ("KG", " lb ") and checks it is supported
+func ParseUnit(s string) (string, error) {
+	unit := strings.ToLower(strings.TrimSpace(s))
+	if _, ok := gramsPer[unit]; !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnknownUnit, s)
+	}
+	return unit, nil
+}
+
+// Convert converts a weight between two supported units
+func Convert(value float64, from, to string) (float64, error) {
+	f, ok := gramsPer[from]
+	if !ok {
+		return 0, fmt.Errorf("%w: %q", ErrUnknownUnit, from)
+	}
+	t, ok := gramsPer[to]
+	if !ok {
+		return 0, fmt.Errorf("%w: %q", ErrUnknownUnit, to)
+	}
+	return value * f / t, nil
+}
+
+// ConvertReading expresses a reading in another unit. The value and tare
+// are rounded to the scale division converted to the target unit and
+// snapped to the nearest 1-2-5 step, as a dual-unit indicator would show
+// them (0.01 kg becomes 0.02 lb). Readings without a unit cannot be
+// converted and return ErrUnknownUnit.
+func ConvertReading(r Reading, to string) (Reading, error) {
+	if r.Unit == to {
+		return r, nil
+	}
+	division, err := Convert(divisionFor(r.Division, r), r.Unit, to)
+	if err != nil {
+		return r, err
+	}
+	step, decimals := roundDivision(division)
+
+	value, _ := Convert(r.Value, r.Unit, to)
+	tare, _ := Convert(r.Tare, r.Unit, to)
+	r.Value = roundToStep(value, step, decimals)
+	r.Tare = roundToStep(tare, step, decimals)
+	r.Division = step
+	r.Decimals = decimals
+	r.Unit = to
+	return r, nil
+}
+
+// roundDivision returns the 1-2-5 step nearest to d and the decimals
+// needed to display it.
+func roundDivision(d float64) (step float64, decimals int) {
+	exp := math.Floor(math.Log10(d))
+	mantissa := d / math.Pow(10, exp)
+
+	best := 1.0
+	for _, m := range []float64{1, 2, 5, 10} {
+		if math.Abs(math.Log(mantissa/m)) < math.Abs(math.Log(mantissa/best)) {
+			best = m
+		}
+	}
+	if best == 10 {
+		best, exp = 1, exp+1
+	}
+	step = best * math.Pow(10, exp)
+	decimals = max(0, int(-exp))
+	return step, decimals
+}
+
+func roundToStep(v, step float64, decimals int) float64 {
+	v = math.Round(v/step) * step
+	factor := math.Pow10(decimals)
+	return math.Round(v*factor) / factor
+}
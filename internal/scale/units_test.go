@@ -0,0 +1,104 @@
+package scale
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/adcondev/scale-daemon/internal/config"
+)
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		value    float64
+		from, to string
+		want     float64
+	}{
+		{1, UnitKilogram, UnitGram, 1000},
+		{1, UnitPound, UnitOunce, 16},
+		{1, UnitKilogram, UnitPound, 2.2046226218},
+		{453.59237, UnitGram, UnitPound, 1},
+	}
+	for _, tt := range tests {
+		got, err := Convert(tt.value, tt.from, tt.to)
+		if err != nil || got-tt.want > 1e-9 || tt.want-got > 1e-9 {
+			t.Errorf("Convert(%v, %s, %s) = %v, %v; want %v", tt.value, tt.from, tt.to, got, err, tt.want)
+		}
+	}
+
+	if _, err := Convert(1, "", UnitKilogram); !errors.Is(err, ErrUnknownUnit) {
+		t.Errorf("Convert from empty unit error = %v, want ErrUnknownUnit", err)
+	}
+}
+
+func TestConvertReading(t *testing.T) {
+	tests := []struct {
+		name string
+		in   Reading
+		to   string
+		wire string
+		div  float64
+	}{
+		{"kg to lb", Reading{Value: 15.00, Decimals: 2, Unit: UnitKilogram}, UnitPound, "33.06", 0.02},
+		{"kg to g", Reading{Value: 1.235, Decimals: 3, Unit: UnitKilogram}, UnitGram, "1235", 1},
+		{"lb to kg", Reading{Value: 10.0, Decimals: 1, Unit: UnitPound}, UnitKilogram, "4.55", 0.05},
+		{"kg division 5 g to oz", Reading{Value: 2.000, Decimals: 3, Division: 0.005, Unit: UnitKilogram}, UnitOunce, "70.6", 0.2},
+		{"same unit", Reading{Value: 3.5, Decimals: 1, Unit: UnitOunce}, UnitOunce, "3.5", 0},
+	}
+	for _, tt := range tests {
+		got, err := ConvertReading(tt.in, tt.to)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+		if got.String() != tt.wire || got.Unit != tt.to || got.Division != tt.div {
+			t.Errorf("%s: got %s %s (division %v), want %s %s (division %v)",
+				tt.name, got, got.Unit, got.Division, tt.wire, tt.to, tt.div)
+		}
+	}
+
+	if _, err := ConvertReading(Reading{Value: 1, Decimals: 2}, UnitPound); !errors.Is(err, ErrUnknownUnit) {
+		t.Errorf("Converting a reading without unit error = %v, want ErrUnknownUnit", err)
+	}
+}
+
+func TestParseUnit(t *testing.T) {
+	if u, err := ParseUnit(" LB "); err != nil || u != UnitPound {
+		t.Errorf("ParseUnit(LB) = %q, %v", u, err)
+	}
+	if _, err := ParseUnit("ton"); !errors.Is(err, ErrUnknownUnit) {
+		t.Errorf("ParseUnit(ton) error = %v, want ErrUnknownUnit", err)
+	}
+}
+
+func TestReaderReadingWithoutUnit(t *testing.T) {
+	cfg := config.New(config.Environment{DefaultPort: "COM_TEST"})
+	broadcast := make(chan Event, 10)
+	r := NewReader(cfg, broadcast)
+	driver, err := driverFor("Rhino BAR 8RS")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The Rhino reports no unit: without unidad the weight cannot be converted
+	r.handleFrame(cfg.Get(), driver, []byte("12.34"))
+	ev := <-broadcast
+	if ev.Reading.Unit != "" || r.Unit() != "" {
+		t.Fatalf("Reading unit = %q, Unit() = %q, want both empty", ev.Reading.Unit, r.Unit())
+	}
+	if _, err := ConvertReading(ev.Reading, UnitPound); !errors.Is(err, ErrUnknownUnit) {
+		t.Errorf("ConvertReading() error = %v, want ErrUnknownUnit", err)
+	}
+
+	cfg.UpdateUnit(UnitKilogram)
+	if got := r.Unit(); got != UnitKilogram {
+		t.Errorf("Unit() before a reading = %q, want the configured kg", got)
+	}
+	r.handleFrame(cfg.Get(), driver, []byte("12.36"))
+	ev = <-broadcast
+	if ev.Reading.Unit != UnitKilogram {
+		t.Fatalf("Reading unit = %q, want kg", ev.Reading.Unit)
+	}
+	if lb, err := ConvertReading(ev.Reading, UnitPound); err != nil || lb.String() != "27.24" {
+		t.Errorf("ConvertReading(lb) = %s, %v, want 27.24", lb, err)
+	}
+}
@@ -0,0 +1,120 @@
+package scale
+
+import (
+	"context"
+	"errors"
+	"math"
+)
+
+// ErrMotionTimeout is returned by WaitStable when the platform was still
+// moving when the wait ended.
+var ErrMotionTimeout = errors.New("el peso no se estabilizó a tiempo")
+
+// ErrNoNewLoad is returned by WaitNewLoad when the platform stayed stable
+// on the previous load until the wait ended.
+var ErrNoNewLoad = errors.New("no se detectó una carga nueva en la plataforma")
+
+// WaitStable waits until ctx is done for the next stable, in-range reading
+// and returns it with the software tare applied. Readings withheld from
+// clients by the publishing policy count too. When ctx ends first it
+// returns ErrMotionTimeout if the platform kept moving, or a *ScaleError
+// with the last error code: ERR_OVERLOAD or ERR_UNDERLOAD for a weight out
+// of range, ERR_SCALE_CONN when the scale is disconnected, ERR_TIMEOUT when
+// no reading arrived at all.
+func (r *Reader) WaitStable(ctx context.Context) (Reading, error) {
+	return r.waitStable(ctx, nil, 0)
+}
+
+// WaitNewLoad is WaitStable for a load placed after prev was read: stable
+// readings are skipped until the platform has been in motion or the weight
+// differs from prev by more than division. A zero division takes the
+// resolution of the reading, a nil prev the next stable reading. When ctx
+// ends on the unchanged load it returns ErrNoNewLoad.
+func (r *Reader) WaitNewLoad(ctx context.Context, prev *Reading, division float64) (Reading, error) {
+	return r.waitStable(ctx, prev, division)
+}
+
+func (r *Reader) waitStable(ctx context.Context, prev *Reading, division float64) (Reading, error) {
+	ch := make(chan Event, 16)
+	r.statusMu.Lock()
+	if r.watchers == nil {
+		r.watchers = make(map[chan Event]struct{})
+	}
+	r.watchers[ch] = struct{}{}
+	code := r.lastCode
+	r.statusMu.Unlock()
+	defer func() {
+		r.statusMu.Lock()
+		delete(r.watchers, ch)
+		r.statusMu.Unlock()
+	}()
+
+	moving, moved, unchanged := false, false, false
+	for {
+		select {
+		case ev := <-ch:
+			switch {
+			case ev.IsError():
+				code, moving = ev.Code, false
+			case ev.Reading.Stable:
+				if prev == nil || moved || newLoad(*prev, ev.Reading, division) {
+					return ev.Reading, nil
+				}
+				code, moving, unchanged = "", false, true
+			default:
+				code, moving, moved = "", true, true
+			}
+		case <-ctx.Done():
+			if unchanged && code == "" && !moving {
+				return Reading{}, ErrNoNewLoad
+			}
+			return Reading{}, r.waitError(code, moving)
+		}
+	}
+}
+
+// newLoad reports whether reading differs from prev by more than division
+func newLoad(prev, reading Reading, division float64) bool {
+	if reading.Unit != prev.Unit {
+		return true
+	}
+	if division == 0 {
+		division = reading.Division
+	}
+	if division == 0 {
+		division = math.Pow10(-reading.Decimals)
+	}
+	return math.Abs(reading.Value-prev.Value) > division+1e-9
+}
+
+// waitError builds the failure of a WaitStable that ran out of time
+func (r *Reader) waitError(code string, moving bool) error {
+	if moving {
+		return ErrMotionTimeout
+	}
+	if code == "" {
+		code = ErrTimeout
+		if r.config != nil && !r.config.Get().ModoPrueba && r.currentPort() == nil {
+			code = ErrConnection
+		}
+	}
+	if last := r.LastError(); last != nil && last.Code == code {
+		return last
+	}
+	puerto := ""
+	if r.config != nil {
+		puerto = r.config.Get().Puerto
+	}
+	return &ScaleError{Code: code, Port: puerto}
+}
+
+// notify hands ev to every WaitStable in progress without blocking.
+// statusMu must be held.
+func (r *Reader) notify(ev Event) {
+	for ch := range r.watchers {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
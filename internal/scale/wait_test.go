@@ -0,0 +1,109 @@
+package scale
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/adcondev/scale-daemon/internal/config"
+)
+
+// waitStable starts WaitStable and returns once it is registered
+func waitStable(r *Reader, timeout time.Duration) <-chan error {
+	done := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		reading, err := r.WaitStable(ctx)
+		if err == nil && reading.String() != "12.50" {
+			err = errors.New("unexpected reading " + reading.String())
+		}
+		done <- err
+	}()
+	for {
+		r.statusMu.Lock()
+		n := len(r.watchers)
+		r.statusMu.Unlock()
+		if n > 0 {
+			return done
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestWaitStable(t *testing.T) {
+	conf := config.Snapshot{
+		Rango:       config.RangeSettings{Capacity: 20},
+		Publicacion: config.PublishSettings{Heartbeat: time.Minute},
+	}
+	for _, tt := range []struct {
+		name   string
+		frames []string
+		check  func(error) bool
+	}{
+		{"settles", []string{"12.40 kg US", "12.50 kg ST"}, func(err error) bool { return err == nil }},
+		{"settles unchanged", []string{"12.50 kg ST", "12.50 kg ST"}, func(err error) bool { return err == nil }},
+		{"keeps moving", []string{"12.40 kg US", "12.60 kg US"}, func(err error) bool {
+			return errors.Is(err, ErrMotionTimeout)
+		}},
+		{"overload", []string{"12.40 kg US", "25.00 kg ST"}, func(err error) bool {
+			return errors.Is(err, &ScaleError{Code: ErrOverload})
+		}},
+	} {
+		r := &Reader{broadcast: make(chan Event, 10)}
+		done := waitStable(r, 200*time.Millisecond)
+		for _, frame := range tt.frames {
+			r.handleFrame(conf, rhinoDriver{}, []byte(frame))
+		}
+		if err := <-done; !tt.check(err) {
+			t.Errorf("%s: WaitStable() error = %v", tt.name, err)
+		}
+	}
+
+	// Nothing arrives from a closed port
+	r := &Reader{config: config.New(config.Environment{}), broadcast: make(chan Event, 10)}
+	if err := <-waitStable(r, 50*time.Millisecond); !errors.Is(err, &ScaleError{Code: ErrConnection}) {
+		t.Errorf("Disconnected: WaitStable() error = %v", err)
+	}
+}
+
+func TestWaitNewLoad(t *testing.T) {
+	conf := config.Snapshot{Publicacion: config.PublishSettings{Heartbeat: time.Minute}}
+	prev := Reading{Value: 12.50, Decimals: 2, Unit: UnitKilogram, Stable: true}
+	for _, tt := range []struct {
+		name     string
+		division float64
+		frames   []string
+		want     string
+		wantErr  error
+	}{
+		{"same load", 0, []string{"12.50 kg ST", "12.51 kg ST"}, "", ErrNoNewLoad},
+		{"lifted and replaced", 0, []string{"12.50 kg ST", "12.30 kg US", "12.50 kg ST"}, "12.50", nil},
+		{"new load", 0, []string{"12.50 kg ST", "15.00 kg ST"}, "15.00", nil},
+		{"within division", 0.05, []string{"12.54 kg ST"}, "", ErrNoNewLoad},
+		{"beyond division", 0.05, []string{"12.54 kg ST", "12.56 kg ST"}, "12.56", nil},
+	} {
+		r := &Reader{broadcast: make(chan Event, 10)}
+		done := make(chan error, 1)
+		var got Reading
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			var err error
+			got, err = r.WaitNewLoad(ctx, &prev, tt.division)
+			done <- err
+		}()
+		for registered := false; !registered; time.Sleep(time.Millisecond) {
+			r.statusMu.Lock()
+			registered = len(r.watchers) > 0
+			r.statusMu.Unlock()
+		}
+		for _, frame := range tt.frames {
+			r.handleFrame(conf, rhinoDriver{}, []byte(frame))
+		}
+		if err := <-done; !errors.Is(err, tt.wantErr) || (err == nil && got.String() != tt.want) {
+			t.Errorf("%s: WaitNewLoad() = %s, %v; want %s, %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -8,47 +8,85 @@ import (
 
 	"github.com/coder/websocket"
 	"github.com/coder/websocket/wsjson"
+
+	"github.com/adcondev/scale-daemon/internal/scale"
 )
 
-// Broadcaster fans out weight readings to all connected clients
+// ClientOptions holds per-connection preferences chosen at connect time
+type ClientOptions struct {
+	// Detalle sends weights as WeightMessage objects instead of the v1
+	// bare string
+	Detalle bool
+	// Unidad converts weights to kg, g, lb or oz; empty keeps the unit
+	// reported by the indicator
+	Unidad string
+	// Estado sends StateMessage objects on every reader state change.
+	// v1 clients treat unknown objects as weights, so it is opt-in.
+	Estado bool
+}
+
+// Broadcaster fans out weight readings and reader state changes to all
+// connected clients
 type Broadcaster struct {
-	clients      map[*websocket.Conn]bool
-	mu           sync.RWMutex
-	broadcast    <-chan string
-	onWeightSent func() // Called after successful weight broadcast
+	clients   map[*websocket.Conn]ClientOptions
+	mu        sync.RWMutex
+	broadcast <-chan scale.Event
+	states    <-chan scale.Transition
 }
 
-// NewBroadcaster creates a broadcaster for the given channel
-func NewBroadcaster(broadcast <-chan string, onWeightSent func()) *Broadcaster {
+// NewBroadcaster creates a broadcaster for the given channels. states may
+// be nil when the reader publishes no state changes.
+func NewBroadcaster(broadcast <-chan scale.Event, states <-chan scale.Transition) *Broadcaster {
 	return &Broadcaster{
-		clients:      make(map[*websocket.Conn]bool),
-		broadcast:    broadcast,
-		onWeightSent: onWeightSent,
+		clients:   make(map[*websocket.Conn]ClientOptions),
+		broadcast: broadcast,
+		states:    states,
 	}
 }
 
-// Start begins broadcasting weights to clients (blocking)
+// Start begins broadcasting weights to clients (blocking). State changes
+// are sent from their own goroutine so a slow client cannot hold up
+// weights.
 func (b *Broadcaster) Start(ctx context.Context) {
+	if b.states != nil {
+		go b.forwardStates(ctx)
+	}
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case peso, ok := <-b.broadcast:
+		case ev, ok := <-b.broadcast:
 			if !ok {
 				return
 			}
-			b.broadcastWeight(peso)
+			if ev.Suppressed {
+				continue
+			}
+			b.broadcastWeight(ev)
 		}
 	}
 }
 
-// broadcastWeight sends raw weight string to all clients
-// CONSTRAINT: Weight is sent as JSON string, NOT wrapped in object
-func (b *Broadcaster) broadcastWeight(peso string) {
+// forwardStates sends state changes to clients until ctx is done. The
+// reader drops changes while the subscription channel is full.
+func (b *Broadcaster) forwardStates(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case tr := <-b.states:
+			b.broadcastState(tr)
+		}
+	}
+}
+
+// broadcastWeight sends a weight or error code to all clients
+// CONSTRAINT: v1 clients get the weight as JSON string, NOT wrapped in object
+func (b *Broadcaster) broadcastWeight(ev scale.Event) {
 	b.mu.RLock()
-	clients := make([]*websocket.Conn, 0, len(b.clients))
-	for c := range b.clients {
-		clients = append(clients, c)
+	clients := make(map[*websocket.Conn]ClientOptions, len(b.clients))
+	for c, opts := range b.clients {
+		clients[c] = opts
 	}
 	b.mu.RUnlock()
 
@@ -56,23 +94,72 @@ func (b *Broadcaster) broadcastWeight(peso string) {
 		return
 	}
 
-	for _, conn := range clients {
-		go func(c *websocket.Conn) {
+	for conn, opts := range clients {
+		go func(c *websocket.Conn, opts ClientOptions) {
 			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 			defer cancel()
 
 			// CRITICAL: wsjson.Write with string sends "12.50" as JSON string
 			// This preserves the exact format expected by clients
-			if err := wsjson.Write(ctx, c, peso); err != nil {
+			var msg interface{} = ev.String()
+			if !ev.IsError() {
+				reading, err := clientReading(ev.Reading, opts.Unidad)
+				switch {
+				case err != nil:
+					// Never send a weight labeled with a unit it is not in
+					msg = ErrorResponse{Tipo: "error", Error: "UNIT_UNKNOWN"}
+				case opts.Detalle:
+					msg = NewWeightMessage(reading)
+				default:
+					msg = reading.String()
+				}
+			}
+			if err := wsjson.Write(ctx, c, msg); err != nil {
+				log.Printf("[!] Error al enviar a cliente: %v", err)
+				b.removeAndCloseClient(c)
+			}
+		}(conn, opts)
+	}
+}
+
+// broadcastState sends a state change to the clients that asked for it,
+// in parallel, and returns once every write is done or timed out. Changes
+// thus arrive in order, so fast transitions such as connecting → backoff
+// are not swapped.
+func (b *Broadcaster) broadcastState(tr scale.Transition) {
+	msg := NewStateMessage(tr.To, tr.At, tr.From, tr.Cause)
+	b.mu.RLock()
+	var clients []*websocket.Conn
+	for c, opts := range b.clients {
+		if opts.Estado {
+			clients = append(clients, c)
+		}
+	}
+	b.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, conn := range clients {
+		wg.Add(1)
+		go func(c *websocket.Conn) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			if err := wsjson.Write(ctx, c, msg); err != nil {
 				log.Printf("[!] Error al enviar a cliente: %v", err)
 				b.removeAndCloseClient(c)
 			}
 		}(conn)
 	}
-	// Record activity after broadcasting to at least one client
-	if b.onWeightSent != nil {
-		b.onWeightSent()
+	wg.Wait()
+}
+
+// clientReading converts a reading to the client's unit. Readings without
+// a unit cannot be converted and return scale.ErrUnknownUnit.
+func clientReading(r scale.Reading, unit string) (scale.Reading, error) {
+	if unit == "" {
+		return r, nil
 	}
+	return scale.ConvertReading(r, unit)
 }
 
 // removeAndCloseClient safely removes and closes a client connection
@@ -93,12 +180,29 @@ func (b *Broadcaster) removeAndCloseClient(conn *websocket.Conn) {
 }
 
 // AddClient registers a new WebSocket connection
-func (b *Broadcaster) AddClient(conn *websocket.Conn) {
+func (b *Broadcaster) AddClient(conn *websocket.Conn, opts ClientOptions) {
 	b.mu.Lock()
-	b.clients[conn] = true
+	b.clients[conn] = opts
 	b.mu.Unlock()
 }
 
+// SetClientUnit changes the display unit of a connected client
+func (b *Broadcaster) SetClientUnit(conn *websocket.Conn, unit string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if opts, ok := b.clients[conn]; ok {
+		opts.Unidad = unit
+		b.clients[conn] = opts
+	}
+}
+
+// ClientUnit returns the display unit of a connected client
+func (b *Broadcaster) ClientUnit(conn *websocket.Conn) string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.clients[conn].Unidad
+}
+
 // RemoveClient unregisters a WebSocket connection
 func (b *Broadcaster) RemoveClient(conn *websocket.Conn) {
 	b.mu.Lock()
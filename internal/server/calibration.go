@@ -0,0 +1,202 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/adcondev/scale-daemon/internal/calibration"
+	"github.com/adcondev/scale-daemon/internal/scale"
+)
+
+// calibrationStatus maps calibration errors to HTTP status and code
+func calibrationStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, calibration.ErrNotFound):
+		return http.StatusNotFound, "CALIBRATION_NOT_FOUND"
+	case errors.Is(err, calibration.ErrSessionActive):
+		return http.StatusConflict, "CALIBRATION_ACTIVE"
+	case errors.Is(err, calibration.ErrInvalidClass):
+		return http.StatusBadRequest, "INVALID_CLASS"
+	case errors.Is(err, calibration.ErrInvalidOptions):
+		return http.StatusBadRequest, "INVALID_CALIBRATION"
+	case errors.Is(err, calibration.ErrInvalidReference):
+		return http.StatusBadRequest, "INVALID_REFERENCE"
+	case errors.Is(err, calibration.ErrUnitMismatch):
+		return http.StatusConflict, "UNIT_MISMATCH"
+	case errors.Is(err, calibration.ErrNoPoints):
+		return http.StatusConflict, "NO_POINTS"
+	case errors.Is(err, calibration.ErrBadSignature):
+		return http.StatusInternalServerError, "INVALID_SIGNATURE"
+	case errors.Is(err, scale.ErrNoNewLoad):
+		return http.StatusUnprocessableEntity, "NO_NEW_LOAD"
+	case errors.Is(err, scale.ErrMotionTimeout), errors.As(err, new(*scale.ScaleError)):
+		// The scale did not deliver a stable reading
+		return http.StatusUnprocessableEntity, captureErrorCode(err)
+	}
+	return http.StatusInternalServerError, "CALIBRATION_FAILED"
+}
+
+// HandleCalibration serves the verification sessions:
+//
+//	POST   /calibration?scale=<id>       start a session
+//	GET    /calibration                  list sessions and reports
+//	GET    /calibration/<id>[?formato=csv]
+//	POST   /calibration/<id>/puntos      capture a reference mass
+//	POST   /calibration/<id>/finalizar   sign and store the report
+//	DELETE /calibration/<id>             cancel an active session
+func (s *Server) HandleCalibration(w http.ResponseWriter, r *http.Request) {
+	if s.calibration == nil {
+		http.Error(w, "CALIBRATION_UNAVAILABLE", http.StatusServiceUnavailable)
+		return
+	}
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/calibration"), "/")
+	id, action, _ := strings.Cut(rest, "/")
+
+	switch {
+	case id == "" && r.Method == http.MethodPost:
+		s.startCalibration(w, r)
+	case id == "" && r.Method == http.MethodGet:
+		reports, err := s.calibration.List()
+		if err != nil {
+			log.Printf("[X] Error listando verificaciones: %v", err)
+			http.Error(w, "CALIBRATION_LIST_FAILED", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, reports)
+	case id != "" && action == "" && r.Method == http.MethodGet:
+		s.calibrationReport(w, r, id)
+	case id != "" && action == "puntos" && r.Method == http.MethodPost:
+		s.measureCalibration(w, r, id)
+	case id != "" && action == "finalizar" && r.Method == http.MethodPost:
+		rep, err := s.calibration.Finish(id)
+		if err != nil {
+			calibrationError(w, err)
+			return
+		}
+		log.Printf("[AUDIT] CALIBRATION_FINISHED | id=%s | scale=%s | puntos=%d | aprobado=%t | client=%s",
+			rep.ID, rep.Bascula, len(rep.Puntos), rep.Aprobado, r.RemoteAddr)
+		writeJSON(w, http.StatusOK, rep)
+	case id != "" && action == "" && r.Method == http.MethodDelete:
+		if err := s.calibration.Cancel(id); err != nil {
+			calibrationError(w, err)
+			return
+		}
+		log.Printf("[AUDIT] CALIBRATION_CANCELED | id=%s | client=%s", id, r.RemoteAddr)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) startCalibration(w http.ResponseWriter, r *http.Request) {
+	sc, ok := s.scaleFor(r)
+	if !ok {
+		http.Error(w, "Báscula desconocida", http.StatusNotFound)
+		return
+	}
+	var req CalibrationStartRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "INVALID_CALIBRATION", http.StatusBadRequest)
+			return
+		}
+	}
+	class, err := calibration.ParseClass(req.Clase)
+	if err != nil {
+		calibrationError(w, err)
+		return
+	}
+
+	conf := sc.Config.Get()
+	division := req.Division
+	if division == 0 {
+		division = conf.Division
+	}
+	rep, err := s.calibration.Start(calibration.Options{
+		Bascula:      conf.ID,
+		Marca:        conf.Marca,
+		Puerto:       conf.Puerto,
+		Clase:        class,
+		Verificacion: req.Verificacion,
+		Division:     division,
+		Operador:     req.Operador,
+	})
+	if err != nil {
+		calibrationError(w, err)
+		return
+	}
+	log.Printf("[AUDIT] CALIBRATION_STARTED | id=%s | scale=%s | clase=%s | verificacion=%s | client=%s",
+		rep.ID, rep.Bascula, rep.Clase, rep.Verificacion, r.RemoteAddr)
+	writeJSON(w, http.StatusCreated, rep)
+}
+
+func (s *Server) measureCalibration(w http.ResponseWriter, r *http.Request, id string) {
+	var req CalibrationPointRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Referencia == nil {
+		http.Error(w, "INVALID_REFERENCE", http.StatusBadRequest)
+		return
+	}
+	timeout, err := captureTimeout(req.TimeoutMs)
+	if err != nil {
+		http.Error(w, "INVALID_TIMEOUT", http.StatusBadRequest)
+		return
+	}
+	rep, err := s.calibration.Report(id)
+	if err != nil || rep.Fin != nil {
+		http.Error(w, "CALIBRATION_NOT_FOUND", http.StatusNotFound)
+		return
+	}
+	sc := s.scaleByID(rep.Bascula)
+	if sc == nil {
+		http.Error(w, "Báscula desconocida", http.StatusNotFound)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+	p, err := s.calibration.Measure(ctx, id, sc.Operator, *req.Referencia)
+	if err != nil {
+		log.Printf("[AUDIT] CALIBRATION_POINT_FAILED | id=%s | scale=%s | referencia=%g | client=%s | %v",
+			id, rep.Bascula, *req.Referencia, r.RemoteAddr, err)
+		calibrationError(w, err)
+		return
+	}
+	log.Printf("[AUDIT] CALIBRATION_POINT | id=%s | scale=%s | referencia=%g | lectura=%g | error=%g | emp=%g | aprobado=%t | client=%s",
+		id, rep.Bascula, p.Referencia, p.Lectura, p.Error, p.EMP, p.Aprobado, r.RemoteAddr)
+	writeJSON(w, http.StatusOK, p)
+}
+
+func (s *Server) calibrationReport(w http.ResponseWriter, r *http.Request, id string) {
+	rep, err := s.calibration.Report(id)
+	if err != nil {
+		calibrationError(w, err)
+		return
+	}
+	if r.URL.Query().Get("formato") == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="verificacion-`+rep.ID+`.csv"`)
+		if err := rep.CSV(w); err != nil {
+			log.Printf("[X] Error escribiendo informe %s: %v", rep.ID, err)
+		}
+		return
+	}
+	writeJSON(w, http.StatusOK, rep)
+}
+
+func calibrationError(w http.ResponseWriter, err error) {
+	status, code := calibrationStatus(err)
+	if status == http.StatusInternalServerError {
+		log.Printf("[X] Error de verificación: %v", err)
+	}
+	http.Error(w, code, status)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
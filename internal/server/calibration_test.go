@@ -0,0 +1,155 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adcondev/scale-daemon/internal/auth"
+	"github.com/adcondev/scale-daemon/internal/calibration"
+	"github.com/adcondev/scale-daemon/internal/config"
+	"github.com/adcondev/scale-daemon/internal/scale"
+)
+
+// client sends requests with the session cookie and without following
+// redirects
+type client struct {
+	t       *testing.T
+	baseURL string
+	session string
+}
+
+func (c client) do(method, path, body string) (int, string) {
+	c.t.Helper()
+	req, err := http.NewRequest(method, c.baseURL+path, strings.NewReader(body))
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	if c.session != "" {
+		req.AddCookie(&http.Cookie{Name: auth.SessionCookieName, Value: c.session})
+	}
+	noRedirect := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := noRedirect.Do(req)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode == http.StatusSeeOther {
+		return resp.StatusCode, resp.Header.Get("Location")
+	}
+	return resp.StatusCode, strings.TrimSpace(string(data))
+}
+
+func TestCalibrationRequiresSession(t *testing.T) {
+	orig := config.PasswordHashB64
+	config.PasswordHashB64 = "aGFzaA=="
+	defer func() { config.PasswordHashB64 = orig }()
+
+	mgr, err := calibration.NewManager(t.TempDir(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mostrador, _ := testScale(t, "mostrador", "COM3")
+	s, ts := newTestServer(t, mgr, mostrador)
+
+	anonymous := client{t: t, baseURL: ts.URL}
+	for _, tt := range []struct{ method, path string }{
+		{http.MethodGet, "/calibration"},
+		{http.MethodPost, "/calibration?scale=mostrador"},
+		{http.MethodPost, "/calibration/20260211-140000-3f9a1c2e/puntos"},
+		{http.MethodGet, "/ports"},
+	} {
+		if status, location := anonymous.do(tt.method, tt.path, ""); status != http.StatusSeeOther || location != "/login" {
+			t.Errorf("%s %s without session = %d %s, want redirect to /login", tt.method, tt.path, status, location)
+		}
+	}
+	stale := client{t: t, baseURL: ts.URL, session: "caducada"}
+	if status, _ := stale.do(http.MethodGet, "/calibration", ""); status != http.StatusSeeOther {
+		t.Errorf("GET /calibration with unknown session = %d, want 303", status)
+	}
+
+	admin := client{t: t, baseURL: ts.URL, session: s.auth.CreateSession()}
+	if status, body := admin.do(http.MethodGet, "/calibration", ""); status != http.StatusOK || body != "null" {
+		t.Errorf("GET /calibration with session = %d %s", status, body)
+	}
+}
+
+func TestCalibrationFlow(t *testing.T) {
+	mgr, err := calibration.NewManager(t.TempDir(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mostrador, op := testScale(t, "mostrador", "COM3")
+	_, ts := newTestServer(t, mgr, mostrador)
+	c := client{t: t, baseURL: ts.URL}
+
+	if status, body := c.do(http.MethodPost, "/calibration?scale=nada", ""); status != http.StatusNotFound {
+		t.Errorf("Start on unknown scale = %d %s", status, body)
+	}
+	if status, body := c.do(http.MethodPost, "/calibration", `{"clase": "V"}`); status != http.StatusBadRequest || body != "INVALID_CLASS" {
+		t.Errorf("Start with class V = %d %s", status, body)
+	}
+	status, body := c.do(http.MethodPost, "/calibration?scale=mostrador", `{"clase": "III", "division": 0.01, "operador": "Inspector 12"}`)
+	if status != http.StatusCreated {
+		t.Fatalf("Start = %d %s", status, body)
+	}
+	var rep calibration.Report
+	if err := json.Unmarshal([]byte(body), &rep); err != nil || rep.Bascula != "mostrador" || rep.Puerto != "COM3" {
+		t.Fatalf("Start = %s, %v", body, err)
+	}
+	if status, body := c.do(http.MethodPost, "/calibration", ""); status != http.StatusConflict || body != "CALIBRATION_ACTIVE" {
+		t.Errorf("Second start = %d %s", status, body)
+	}
+
+	points := "/calibration/" + rep.ID + "/puntos"
+	reading := func(value float64) scale.Reading {
+		return scale.Reading{Value: value, Decimals: 2, Unit: "kg", Stable: true, Mode: scale.ModeGross, Time: time.Now()}
+	}
+	for _, tt := range []struct {
+		name    string
+		reading scale.Reading
+		err     error
+		body    string
+		status  int
+		want    string
+	}{
+		{"no reference", reading(10.01), nil, `{}`, http.StatusBadRequest, "INVALID_REFERENCE"},
+		{"bad timeout", reading(10.01), nil, `{"referencia": 10, "timeoutMs": 60000}`, http.StatusBadRequest, "INVALID_TIMEOUT"},
+		{"moving", scale.Reading{}, scale.ErrMotionTimeout, `{"referencia": 10, "timeoutMs": 100}`, http.StatusUnprocessableEntity, "MOTION_TIMEOUT"},
+		{"first mass", reading(10.01), nil, `{"referencia": 10, "timeoutMs": 100}`, http.StatusOK, `"aprobado":true`},
+		{"same mass left on", reading(10.01), nil, `{"referencia": 20, "timeoutMs": 100}`, http.StatusUnprocessableEntity, "NO_NEW_LOAD"},
+		{"second mass", reading(19.97), nil, `{"referencia": 20, "timeoutMs": 100}`, http.StatusOK, `"aprobado":false`},
+	} {
+		op.set(tt.reading, tt.err)
+		if status, body := c.do(http.MethodPost, points, tt.body); status != tt.status || !strings.Contains(body, tt.want) {
+			t.Errorf("%s: POST puntos = %d %s, want %d %s", tt.name, status, body, tt.status, tt.want)
+		}
+	}
+
+	status, body = c.do(http.MethodPost, "/calibration/"+rep.ID+"/finalizar", "")
+	if status != http.StatusOK {
+		t.Fatalf("Finish = %d %s", status, body)
+	}
+	if err := json.Unmarshal([]byte(body), &rep); err != nil || len(rep.Puntos) != 2 || rep.Aprobado || rep.Firma == "" {
+		t.Errorf("Finish = %s, %v", body, err)
+	}
+	if status, body := c.do(http.MethodPost, points, `{"referencia": 10}`); status != http.StatusNotFound {
+		t.Errorf("Point after finish = %d %s", status, body)
+	}
+	if status, body := c.do(http.MethodGet, "/calibration/"+rep.ID+"?formato=csv", ""); status != http.StatusOK ||
+		!strings.Contains(body, "firma,"+rep.Firma) {
+		t.Errorf("CSV = %d %s", status, body)
+	}
+	if status, body := c.do(http.MethodDelete, "/calibration/"+rep.ID, ""); status != http.StatusNotFound {
+		t.Errorf("Cancel after finish = %d %s", status, body)
+	}
+	if status, _ := c.do(http.MethodPut, "/calibration", ""); status != http.StatusMethodNotAllowed {
+		t.Errorf("PUT /calibration = %d", status)
+	}
+}
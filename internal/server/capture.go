@@ -0,0 +1,155 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/coder/websocket"
+
+	"github.com/adcondev/scale-daemon/internal/scale"
+)
+
+// Capture wait bounds. The maximum stays below the HTTP write timeout.
+const (
+	defaultCaptureTimeout = 5 * time.Second
+	minCaptureTimeout     = 100 * time.Millisecond
+	maxCaptureTimeout     = 10 * time.Second
+)
+
+// errInvalidCaptureTimeout is returned for a timeoutMs outside the bounds
+var errInvalidCaptureTimeout = errors.New("timeoutMs fuera de rango")
+
+// captureTimeout converts timeoutMs to a wait; zero means the default
+func captureTimeout(timeoutMs int) (time.Duration, error) {
+	if timeoutMs == 0 {
+		return defaultCaptureTimeout, nil
+	}
+	timeout := time.Duration(timeoutMs) * time.Millisecond
+	if timeout < minCaptureTimeout || timeout > maxCaptureTimeout {
+		return 0, errInvalidCaptureTimeout
+	}
+	return timeout, nil
+}
+
+// capture waits for a stable weight on sc and records the outcome in the
+// audit log. unit converts the weight; empty keeps the indicator's.
+func (s *Server) capture(ctx context.Context, sc *Scale, timeout time.Duration, unit, client string) CaptureResponse {
+	resp := CaptureResponse{Tipo: "captura", ID: newCaptureID(), Bascula: sc.ID()}
+
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	reading, err := sc.Operator.WaitStable(waitCtx)
+	if err != nil {
+		resp.Error = captureErrorCode(err)
+		resp.Timestamp = time.Now().Format(time.RFC3339Nano)
+		log.Printf("[AUDIT] CAPTURE_FAILED | id=%s | scale=%s | error=%s | client=%s | %v",
+			resp.ID, resp.Bascula, resp.Error, client, err)
+		return resp
+	}
+
+	if reading, err = clientReading(reading, unit); err != nil {
+		resp.Error = "UNIT_UNKNOWN"
+		resp.Timestamp = reading.Time.Format(time.RFC3339Nano)
+		log.Printf("[AUDIT] CAPTURE_FAILED | id=%s | scale=%s | error=%s | client=%s | %v",
+			resp.ID, resp.Bascula, resp.Error, client, err)
+		return resp
+	}
+	resp.OK = true
+	resp.Peso = reading.String()
+	resp.Valor = reading.Value
+	resp.Unidad = reading.Unit
+	resp.Negativo = reading.Negative()
+	resp.Modo = string(reading.Mode)
+	resp.Timestamp = reading.Time.Format(time.RFC3339Nano)
+	log.Printf("[AUDIT] CAPTURE_OK | id=%s | scale=%s | peso=%s %s | modo=%s | client=%s",
+		resp.ID, resp.Bascula, resp.Peso, resp.Unidad, resp.Modo, client)
+	return resp
+}
+
+// captureErrorCode maps a failed capture to the code sent to clients
+func captureErrorCode(err error) string {
+	if errors.Is(err, scale.ErrMotionTimeout) {
+		return "MOTION_TIMEOUT"
+	}
+	return scale.ErrorCode(err)
+}
+
+// newCaptureID returns a unique, time-sortable capture ID such as
+// 20260211-140000-3f9a1c2e
+func newCaptureID() string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return time.Now().Format("20060102-150405") + "-" + hex.EncodeToString(b)
+}
+
+func (s *Server) handleCaptureMessage(ctx context.Context, c *websocket.Conn, sc *Scale, mensaje map[string]interface{}) {
+	data, _ := json.Marshal(mensaje)
+	var captureMsg CaptureMessage
+	if err := json.Unmarshal(data, &captureMsg); err != nil {
+		log.Printf("[X] Error parsing capture message: %v", err)
+		return
+	}
+
+	timeout, err := captureTimeout(captureMsg.TimeoutMs)
+	if err != nil {
+		s.sendJSON(ctx, c, ErrorResponse{Tipo: "error", Error: "INVALID_TIMEOUT"})
+		return
+	}
+	// Wait in the background so the client's other messages are still read
+	unit, client := sc.Broadcaster.ClientUnit(c), fmt.Sprintf("%p", c)
+	go func() {
+		s.sendJSON(ctx, c, s.capture(ctx, sc, timeout, unit, client))
+	}()
+}
+
+// HandleCapture is the HTTP equivalent of the capture message:
+// POST /capture?scale=<id>&timeoutMs=<ms>&unidad=<unit>
+func (s *Server) HandleCapture(w http.ResponseWriter, r *http.Request) {
+	if !s.allowOrigin(w, r) {
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	sc, ok := s.scaleFor(r)
+	if !ok {
+		http.Error(w, "Báscula desconocida", http.StatusNotFound)
+		return
+	}
+
+	query := r.URL.Query()
+	timeoutMs := 0
+	if v := query.Get("timeoutMs"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, "INVALID_TIMEOUT", http.StatusBadRequest)
+			return
+		}
+		timeoutMs = n
+	}
+	timeout, err := captureTimeout(timeoutMs)
+	if err != nil {
+		http.Error(w, "INVALID_TIMEOUT", http.StatusBadRequest)
+		return
+	}
+	unit := ""
+	if u := query.Get("unidad"); u != "" {
+		if unit, err = scale.ParseUnit(u); err != nil {
+			http.Error(w, "INVALID_UNIT", http.StatusBadRequest)
+			return
+		}
+	}
+
+	resp := s.capture(r.Context(), sc, timeout, unit, r.RemoteAddr)
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/adcondev/scale-daemon/internal/scale"
+)
+
+func postCapture(t *testing.T, url, origin string) (*http.Response, CaptureResponse) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	var body CaptureResponse
+	if resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatalf("Decode() error: %v", err)
+		}
+	}
+	return resp, body
+}
+
+func TestHandleCapture(t *testing.T) {
+	mostrador, op := testScale(t, "mostrador", "COM3")
+	piso, _ := testScale(t, "piso", "COM4")
+	_, ts := newTestServer(t, nil, mostrador, piso)
+
+	stable := scale.Reading{Value: 12.5, Decimals: 2, Unit: "kg", Stable: true, Mode: scale.ModeGross, Time: time.Now()}
+	op.set(stable, nil)
+	resp, got := postCapture(t, ts.URL+"/capture?scale=mostrador&unidad=g", "")
+	if resp.StatusCode != http.StatusOK || !got.OK || got.Peso != "12500" || got.Unidad != "g" ||
+		got.Bascula != "mostrador" || got.ID == "" {
+		t.Errorf("Capture = %d %+v, want 12500 g", resp.StatusCode, got)
+	}
+
+	for _, tt := range []struct {
+		err  error
+		want string
+	}{
+		{scale.ErrMotionTimeout, "MOTION_TIMEOUT"},
+		{&scale.ScaleError{Code: scale.ErrOverload}, scale.ErrOverload},
+		{&scale.StatusError{Code: scale.ErrConnection, Detail: "puerto serial no conectado"}, scale.ErrConnection},
+	} {
+		op.set(scale.Reading{}, tt.err)
+		start := time.Now()
+		resp, got := postCapture(t, ts.URL+"/capture?timeoutMs=100", "")
+		if resp.StatusCode != http.StatusOK || got.OK || got.Error != tt.want {
+			t.Errorf("Capture with %v = %d %+v, want %s", tt.err, resp.StatusCode, got, tt.want)
+		}
+		if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+			t.Errorf("Capture with %v returned after %v, before timeoutMs", tt.err, elapsed)
+		}
+	}
+
+	for _, tt := range []struct {
+		method, url, origin string
+		want                int
+	}{
+		{http.MethodPost, "/capture?scale=nada", "", http.StatusNotFound},
+		{http.MethodPost, "/capture?timeoutMs=50", "", http.StatusBadRequest},
+		{http.MethodPost, "/capture?timeoutMs=abc", "", http.StatusBadRequest},
+		{http.MethodPost, "/capture?unidad=st", "", http.StatusBadRequest},
+		{http.MethodPost, "/capture", "https://evil.example", http.StatusForbidden},
+		{http.MethodGet, "/capture", "", http.StatusMethodNotAllowed},
+	} {
+		req, _ := http.NewRequest(tt.method, ts.URL+tt.url, nil)
+		if tt.origin != "" {
+			req.Header.Set("Origin", tt.origin)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != tt.want {
+			t.Errorf("%s %s (origin %q) = %d, want %d", tt.method, tt.url, tt.origin, resp.StatusCode, tt.want)
+		}
+		if got := resp.Header.Get("Access-Control-Allow-Origin"); got != "" {
+			t.Errorf("%s %s sent Access-Control-Allow-Origin %q", tt.method, tt.url, got)
+		}
+	}
+}
+
+func TestCaptureMessageRateLimited(t *testing.T) {
+	mostrador, op := testScale(t, "mostrador", "COM3")
+	op.set(scale.Reading{Value: 1, Decimals: 2, Unit: "kg", Stable: true, Time: time.Now()}, nil)
+	_, ts := newTestServer(t, nil, mostrador)
+	c, _ := dial(t, ts, "/ws")
+
+	for i := 0; i < maxConfigChangesPerMinute; i++ {
+		sendMessage(t, c, map[string]interface{}{"tipo": "capture"})
+		if msg := readMessage(t, c); msg["tipo"] != "captura" || msg["ok"] != true {
+			t.Fatalf("Capture %d = %v", i, msg)
+		}
+	}
+	sendMessage(t, c, map[string]interface{}{"tipo": "capture"})
+	if msg := readMessage(t, c); msg["error"] != "RATE_LIMITED" {
+		t.Errorf("Capture over the limit = %v, want RATE_LIMITED", msg)
+	}
+}
@@ -1,5 +1,12 @@
 package server
 
+import (
+	"time"
+
+	"github.com/adcondev/scale-daemon/internal/config"
+	"github.com/adcondev/scale-daemon/internal/scale"
+)
+
 // ConfigMessage matches the exact JSON structure from clients.
 // AuthToken is required when AuthToken is set at build time.
 // CONSTRAINT: All fields must match legacy format exactly. JSON fields can't be changed or be removed.
@@ -11,6 +18,295 @@ type ConfigMessage struct {
 	Dir        string `json:"dir,omitempty"`
 	//nolint:gosec
 	AuthToken string `json:"auth_token"` // Required for config changes
+
+	// Optional serial line parameters; omitted fields keep their current value
+	Baudios          int     `json:"baudios,omitempty"`
+	BitsDatos        int     `json:"bitsDatos,omitempty"`
+	Paridad          string  `json:"paridad,omitempty"`
+	BitsParada       float64 `json:"bitsParada,omitempty"`
+	TimeoutLecturaMs int     `json:"timeoutLecturaMs,omitempty"`
+
+	// Optional read mode: "auto", "poll" or "continuous"
+	ModoLectura string `json:"modoLectura,omitempty"`
+
+	// Optional stability detection; nil or zero fields keep their current value
+	VentanaEstabilidad    int      `json:"ventanaEstabilidad,omitempty"`
+	ToleranciaEstabilidad *float64 `json:"toleranciaEstabilidad,omitempty"`
+	SoloEstable           *bool    `json:"soloEstable,omitempty"`
+	Division              *float64 `json:"division,omitempty"`
+
+	// Optional unit of readings whose frame has none; "" clears it
+	Unidad *string `json:"unidad,omitempty"`
+
+	// Optional test mode scenario file; "" restores the built-in scenario
+	Escenario *string `json:"escenario,omitempty"`
+
+	// Optional serial capture file name in the captures directory; "" stops
+	// the capture
+	Captura *string `json:"captura,omitempty"`
+
+	// Optional reconnect backoff; nil or zero fields keep their current value
+	ReintentoInicialMs int      `json:"reintentoInicialMs,omitempty"`
+	ReintentoMaxMs     int      `json:"reintentoMaxMs,omitempty"`
+	ReintentoFactor    float64  `json:"reintentoFactor,omitempty"`
+	ReintentoJitter    *float64 `json:"reintentoJitter,omitempty"`
+
+	// Optional publishing policy; nil fields keep their current value
+	BandaMuerta *float64 `json:"bandaMuerta,omitempty"`
+	LatidoMs    *int     `json:"latidoMs,omitempty"`
+
+	// Optional weighing range in the indicator's unit; 0 disables a check
+	Capacidad *float64 `json:"capacidad,omitempty"`
+	Minimo    *float64 `json:"minimo,omitempty"`
+}
+
+// SerialSettings converts the optional line fields to config settings
+func (m ConfigMessage) SerialSettings() config.SerialSettings {
+	return config.SerialSettings{
+		BaudRate:    m.Baudios,
+		DataBits:    m.BitsDatos,
+		Parity:      m.Paridad,
+		StopBits:    m.BitsParada,
+		ReadTimeout: time.Duration(m.TimeoutLecturaMs) * time.Millisecond,
+	}
+}
+
+// StabilitySettings merges the optional stability fields over current
+func (m ConfigMessage) StabilitySettings(current config.StabilitySettings, division float64) (config.StabilitySettings, float64) {
+	if m.VentanaEstabilidad != 0 {
+		current.Window = m.VentanaEstabilidad
+	}
+	if m.ToleranciaEstabilidad != nil {
+		current.Tolerance = *m.ToleranciaEstabilidad
+	}
+	if m.SoloEstable != nil {
+		current.StableOnly = *m.SoloEstable
+	}
+	if m.Division != nil {
+		division = *m.Division
+	}
+	return current, division
+}
+
+// RetrySettings merges the optional backoff fields over current
+func (m ConfigMessage) RetrySettings(current config.RetrySettings) config.RetrySettings {
+	if m.ReintentoInicialMs != 0 {
+		current.Initial = time.Duration(m.ReintentoInicialMs) * time.Millisecond
+	}
+	if m.ReintentoMaxMs != 0 {
+		current.Max = time.Duration(m.ReintentoMaxMs) * time.Millisecond
+	}
+	if m.ReintentoFactor != 0 {
+		current.Multiplier = m.ReintentoFactor
+	}
+	if m.ReintentoJitter != nil {
+		current.Jitter = *m.ReintentoJitter
+	}
+	return current
+}
+
+// PublishSettings merges the optional publishing fields over current
+func (m ConfigMessage) PublishSettings(current config.PublishSettings) config.PublishSettings {
+	if m.BandaMuerta != nil {
+		current.Deadband = *m.BandaMuerta
+	}
+	if m.LatidoMs != nil {
+		current.Heartbeat = time.Duration(*m.LatidoMs) * time.Millisecond
+	}
+	return current
+}
+
+// RangeSettings merges the optional range fields over current
+func (m ConfigMessage) RangeSettings(current config.RangeSettings) config.RangeSettings {
+	if m.Capacidad != nil {
+		current.Capacity = *m.Capacidad
+	}
+	if m.Minimo != nil {
+		current.Minimum = *m.Minimo
+	}
+	return current
+}
+
+// WeightMessage is the detailed weight sent to clients connected with
+// ?detalle=1 instead of the v1 bare string
+type WeightMessage struct {
+	Tipo      string  `json:"tipo"`
+	Peso      string  `json:"peso"`
+	Valor     float64 `json:"valor"`
+	Unidad    string  `json:"unidad"`
+	Estable   bool    `json:"estable"`
+	Negativo  bool    `json:"negativo"`
+	Modo      string  `json:"modo"`
+	Timestamp string  `json:"timestamp"`
+}
+
+// NewWeightMessage builds the detailed message for a reading
+func NewWeightMessage(r scale.Reading) WeightMessage {
+	return WeightMessage{
+		Tipo:      "peso",
+		Peso:      r.String(),
+		Valor:     r.Value,
+		Unidad:    r.Unit,
+		Estable:   r.Stable,
+		Negativo:  r.Negative(),
+		Modo:      string(r.Mode),
+		Timestamp: r.Time.Format(time.RFC3339Nano),
+	}
+}
+
+// StateMessage reports the reader state to clients connected with
+// ?estado=1: once on connection, then on every change
+type StateMessage struct {
+	Tipo     string `json:"tipo"` // always "estado"
+	Estado   string `json:"estado"`
+	Anterior string `json:"anterior,omitempty"` // omitted on connection
+	Desde    string `json:"desde"`              // when Estado was entered
+	Causa    string `json:"causa,omitempty"`    // ERR_* code behind the change
+}
+
+// NewStateMessage builds the message for a state entered at since
+func NewStateMessage(state scale.State, since time.Time, from scale.State, cause string) StateMessage {
+	return StateMessage{
+		Tipo:     "estado",
+		Estado:   string(state),
+		Anterior: string(from),
+		Desde:    since.Format(time.RFC3339Nano),
+		Causa:    cause,
+	}
+}
+
+// OperationMessage requests a tare or zero operation on the active scale.
+// AuthToken is required like in ConfigMessage.
+type OperationMessage struct {
+	Tipo string `json:"tipo"` // tare, zero, clearTare or presetTare
+	//nolint:gosec
+	AuthToken string `json:"auth_token"`
+
+	// Preset tare value and unit; the unit defaults to the current reading's
+	Valor  float64 `json:"valor,omitempty"`
+	Unidad string  `json:"unidad,omitempty"`
+}
+
+// OperationResponse reports the outcome of an OperationMessage
+type OperationResponse struct {
+	Tipo      string  `json:"tipo"` // always "resultado"
+	Operacion string  `json:"operacion"`
+	OK        bool    `json:"ok"`
+	Valor     float64 `json:"valor,omitempty"`
+	Unidad    string  `json:"unidad,omitempty"`
+	Origen    string  `json:"origen,omitempty"` // "indicador" or "software"
+	Error     string  `json:"error,omitempty"`
+}
+
+// UnitsMessage selects the unit weights are sent in for this client.
+// An empty Unidad restores the indicator's own unit.
+type UnitsMessage struct {
+	Tipo   string `json:"tipo"` // "units"
+	Unidad string `json:"unidad"`
+}
+
+// CaptureMessage requests one stable weight, waiting up to TimeoutMs
+// (default 5000) for the platform to settle
+type CaptureMessage struct {
+	Tipo      string `json:"tipo"` // "capture"
+	TimeoutMs int    `json:"timeoutMs,omitempty"`
+}
+
+// CaptureResponse reports a captured weight, or why none could be taken.
+// Every capture, successful or not, gets an ID traceable in the audit log.
+type CaptureResponse struct {
+	Tipo      string  `json:"tipo"` // always "captura"
+	ID        string  `json:"id"`
+	Bascula   string  `json:"bascula"`
+	OK        bool    `json:"ok"`
+	Peso      string  `json:"peso,omitempty"`
+	Valor     float64 `json:"valor,omitempty"`
+	Unidad    string  `json:"unidad,omitempty"`
+	Negativo  bool    `json:"negativo,omitempty"`
+	Modo      string  `json:"modo,omitempty"`
+	Timestamp string  `json:"timestamp"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// CalibrationStartRequest is the optional body of POST /calibration. An
+// omitted division takes the scale's configured one.
+type CalibrationStartRequest struct {
+	Clase        string  `json:"clase"`        // I, II, III (default) or IIII
+	Verificacion string  `json:"verificacion"` // "inicial" (default) or "servicio"
+	Division     float64 `json:"division"`
+	Operador     string  `json:"operador"`
+}
+
+// CalibrationPointRequest is the body of POST /calibration/<id>/puntos
+type CalibrationPointRequest struct {
+	Referencia *float64 `json:"referencia"` // reference mass in the scale unit
+	TimeoutMs  int      `json:"timeoutMs"`
+}
+
+// PortListResponse lists the serial ports present on the host
+type PortListResponse struct {
+	Tipo    string     `json:"tipo"` // always "portList"
+	Puertos []PortInfo `json:"puertos"`
+}
+
+// PortInfo describes a serial port; USB fields are empty for built-in ports
+type PortInfo struct {
+	Nombre   string `json:"nombre"`
+	USB      bool   `json:"usb"`
+	VID      string `json:"vid,omitempty"`
+	PID      string `json:"pid,omitempty"`
+	Serie    string `json:"serie,omitempty"`
+	Producto string `json:"producto,omitempty"`
+	EnUso    string `json:"enUso,omitempty"` // ID of the scale reading this port
+}
+
+// ProbeMessage requests an auto-probe of serial ports. Ports in use are
+// skipped unless Adoptar is set, in which case the requesting scale's own
+// port is probed too and the first match becomes its configuration.
+type ProbeMessage struct {
+	Tipo string `json:"tipo"` // "probe"
+	//nolint:gosec
+	AuthToken string   `json:"auth_token"`
+	Puertos   []string `json:"puertos,omitempty"` // defaults to every free port
+	Adoptar   bool     `json:"adoptar,omitempty"`
+}
+
+// ProbeResponse reports which port, driver and line combinations answered
+type ProbeResponse struct {
+	Tipo       string         `json:"tipo"` // always "probeResult"
+	Resultados []ProbeMatch   `json:"resultados"`
+	Omitidos   []ProbeSkipped `json:"omitidos"`
+	Adoptado   *ProbeMatch    `json:"adoptado"`
+}
+
+// ProbeMatch is one combination that produced a valid weight
+type ProbeMatch struct {
+	Puerto     string  `json:"puerto"`
+	Marca      string  `json:"marca"`
+	Baudios    int     `json:"baudios"`
+	BitsDatos  int     `json:"bitsDatos"`
+	Paridad    string  `json:"paridad"`
+	BitsParada float64 `json:"bitsParada"`
+	Peso       string  `json:"peso"`
+}
+
+// NewProbeMatch converts a scale probe result for clients
+func NewProbeMatch(r scale.ProbeResult) ProbeMatch {
+	return ProbeMatch{
+		Puerto:     r.Port,
+		Marca:      r.Brand,
+		Baudios:    r.Serial.BaudRate,
+		BitsDatos:  r.Serial.DataBits,
+		Paridad:    r.Serial.Parity,
+		BitsParada: r.Serial.StopBits,
+		Peso:       r.Reading.String(),
+	}
+}
+
+// ProbeSkipped is a requested port that was not probed
+type ProbeSkipped struct {
+	Puerto string `json:"puerto"`
+	Motivo string `json:"motivo"` // "en_uso" or "desconocido"
 }
 
 // ErrorResponse is sent back to clients when an operation is rejected
@@ -24,33 +320,80 @@ type EnvironmentInfo struct {
 	Tipo     string          `json:"tipo"`
 	Ambiente string          `json:"ambiente"`
 	Version  string          `json:"version"`
+	Basculas []string        `json:"basculas"` // configured scale IDs, default first
 	Config   ConfigForClient `json:"config"`
 }
 
 // ConfigForClient is the config subset sent to clients
 type ConfigForClient struct {
 	Tipo       string `json:"tipo,omitempty"`
+	ID         string `json:"id"`
 	Puerto     string `json:"puerto"`
 	Marca      string `json:"marca"`
 	ModoPrueba bool   `json:"modoPrueba"`
 	Dir        string `json:"dir"`
 	Ambiente   string `json:"ambiente"`
+
+	Baudios          int     `json:"baudios"`
+	BitsDatos        int     `json:"bitsDatos"`
+	Paridad          string  `json:"paridad"`
+	BitsParada       float64 `json:"bitsParada"`
+	TimeoutLecturaMs int     `json:"timeoutLecturaMs"`
+	ModoLectura      string  `json:"modoLectura"`
+
+	VentanaEstabilidad    int     `json:"ventanaEstabilidad"`
+	ToleranciaEstabilidad float64 `json:"toleranciaEstabilidad"`
+	SoloEstable           bool    `json:"soloEstable"`
+	Division              float64 `json:"division"`
+	Unidad                string  `json:"unidad"`
+	Escenario             string  `json:"escenario"`
+	Captura               string  `json:"captura"`
+
+	ReintentoInicialMs int     `json:"reintentoInicialMs"`
+	ReintentoMaxMs     int     `json:"reintentoMaxMs"`
+	ReintentoFactor    float64 `json:"reintentoFactor"`
+	ReintentoJitter    float64 `json:"reintentoJitter"`
+
+	BandaMuerta float64 `json:"bandaMuerta"`
+	LatidoMs    int     `json:"latidoMs"`
+
+	Capacidad float64 `json:"capacidad"`
+	Minimo    float64 `json:"minimo"`
 }
 
 // HealthResponse represents service health (excludes weight data per protocol)
 type HealthResponse struct {
-	Status string      `json:"status"`
-	Scale  ScaleStatus `json:"scale"`
-	Build  BuildInfo   `json:"build"`
-	Uptime int         `json:"uptime_seconds"`
+	Status string        `json:"status"`
+	Scale  ScaleStatus   `json:"scale"`  // default scale
+	Scales []ScaleStatus `json:"scales"` // every scale, default first
+	Build  BuildInfo     `json:"build"`
+	Uptime int           `json:"uptime_seconds"`
 }
 
 // ScaleStatus represents scale configuration state (no payload data)
 type ScaleStatus struct {
-	Connected bool   `json:"connected"`
-	Port      string `json:"port"`
-	Brand     string `json:"brand"`
-	TestMode  bool   `json:"test_mode"`
+	ID         string `json:"id"`
+	Connected  bool   `json:"connected"`
+	Port       string `json:"port"`
+	Brand      string `json:"brand"`
+	TestMode   bool   `json:"test_mode"`
+	Line       string `json:"line"`
+	ReadMode   string `json:"read_mode"`
+	StableOnly bool   `json:"stable_only"`
+	// State is the reader state; StateSince is when it was entered (RFC 3339)
+	State        string     `json:"state"`
+	StateSince   string     `json:"state_since,omitempty"`
+	StateSeconds int        `json:"state_seconds"`
+	Retry        *RetryInfo `json:"retry,omitempty"` // only while reconnecting or failing
+	// Errors counts each ERR_* code since the service started
+	Errors map[string]uint64 `json:"errors,omitempty"`
+}
+
+// RetryInfo reports the reconnect backoff of a scale
+type RetryInfo struct {
+	Attempts    int    `json:"attempts"`
+	NextAttempt string `json:"next_attempt,omitempty"` // RFC 3339
+	LastError   string `json:"last_error,omitempty"`
 }
 
 // BuildInfo contains build metadata
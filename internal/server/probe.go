@@ -0,0 +1,209 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/coder/websocket"
+
+	"github.com/adcondev/scale-daemon/internal/config"
+	"github.com/adcondev/scale-daemon/internal/scale"
+)
+
+// probeTimeout bounds a whole probe run; each port takes a few seconds
+const probeTimeout = 2 * time.Minute
+
+// portList enumerates the host serial ports and marks those read by a scale
+func (s *Server) portList() (PortListResponse, error) {
+	ports, err := scale.ListPorts()
+	if err != nil {
+		return PortListResponse{}, err
+	}
+	resp := PortListResponse{Tipo: "portList", Puertos: make([]PortInfo, 0, len(ports))}
+	for _, p := range ports {
+		info := PortInfo{
+			Nombre:   p.Name,
+			USB:      p.USB,
+			VID:      p.VID,
+			PID:      p.PID,
+			Serie:    p.SerialNumber,
+			Producto: p.Product,
+		}
+		if sc := s.scaleOnPort(p.Name); sc != nil {
+			info.EnUso = sc.ID()
+		}
+		resp.Puertos = append(resp.Puertos, info)
+	}
+	return resp, nil
+}
+
+// scaleOnPort returns the scale whose reader holds port, or nil. Scales in
+// test mode do not open their port.
+func (s *Server) scaleOnPort(port string) *Scale {
+	for _, sc := range s.scales {
+		cfg := sc.Config.Get()
+		if !cfg.ModoPrueba && strings.EqualFold(cfg.Puerto, port) {
+			return sc
+		}
+	}
+	return nil
+}
+
+// HandlePorts lists the host serial ports as JSON, like the listPorts
+// message. USB serial numbers identify the hardware, so the route requires
+// a dashboard session.
+func (s *Server) HandlePorts(w http.ResponseWriter, _ *http.Request) {
+	resp, err := s.portList()
+	if err != nil {
+		log.Printf("[X] Error listando puertos: %v", err)
+		http.Error(w, "PORT_LIST_FAILED", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (s *Server) handleListPortsMessage(ctx context.Context, c *websocket.Conn) {
+	resp, err := s.portList()
+	if err != nil {
+		log.Printf("[X] Error listando puertos: %v", err)
+		s.sendJSON(ctx, c, ErrorResponse{Tipo: "error", Error: "PORT_LIST_FAILED"})
+		return
+	}
+	s.sendJSON(ctx, c, resp)
+}
+
+func (s *Server) handleProbeMessage(ctx context.Context, c *websocket.Conn, sc *Scale, mensaje map[string]interface{}) {
+	data, _ := json.Marshal(mensaje)
+	var probeMsg ProbeMessage
+	if err := json.Unmarshal(data, &probeMsg); err != nil {
+		log.Printf("[X] Error parsing probe message: %v", err)
+		return
+	}
+
+	// ── TOKEN VALIDATION ─────────────────────────────────────
+	if config.AuthToken != "" && probeMsg.AuthToken != config.AuthToken {
+		log.Printf("[AUDIT] PROBE_REJECTED | reason=invalid_token | scale=%s", sc.ID())
+		s.sendJSON(ctx, c, ErrorResponse{Tipo: "error", Error: "AUTH_INVALID_TOKEN"})
+		return
+	}
+
+	// ── SINGLE PROBE ─────────────────────────────────────────
+	// Two probes would fight over the same ports
+	if !s.probeMu.TryLock() {
+		log.Printf("[AUDIT] PROBE_REJECTED | reason=busy | scale=%s", sc.ID())
+		s.sendJSON(ctx, c, ErrorResponse{Tipo: "error", Error: "PROBE_BUSY"})
+		return
+	}
+
+	// Probe in the background so the client's other messages are still read
+	go func() {
+		defer s.probeMu.Unlock()
+		s.sendJSON(ctx, c, s.probe(ctx, sc, probeMsg))
+	}()
+}
+
+// probe runs a port probe for sc and, if requested, adopts the first
+// match. The caller holds probeMu.
+func (s *Server) probe(ctx context.Context, sc *Scale, probeMsg ProbeMessage) interface{} {
+	resp := ProbeResponse{Tipo: "probeResult", Resultados: []ProbeMatch{}, Omitidos: []ProbeSkipped{}}
+
+	hostPorts, err := scale.ListPorts()
+	if err != nil {
+		log.Printf("[X] Error listando puertos: %v", err)
+		return ErrorResponse{Tipo: "error", Error: "PORT_LIST_FAILED"}
+	}
+	requested := probeMsg.Puertos
+	if len(requested) == 0 {
+		for _, p := range hostPorts {
+			requested = append(requested, p.Name)
+		}
+	}
+
+	// ── PORT SELECTION ───────────────────────────────────────
+	// Only the host's serial ports are probed: a client must not make the
+	// daemon dial network hosts or read replay files. Ports held by a
+	// reader are left alone, except the requesting scale's own port when
+	// the client asked to adopt the result.
+	var ports []string
+	pauseOwn := false
+	for _, name := range requested {
+		port, ok := hostPort(hostPorts, name)
+		if !ok {
+			resp.Omitidos = append(resp.Omitidos, ProbeSkipped{Puerto: name, Motivo: "desconocido"})
+			continue
+		}
+		owner := s.scaleOnPort(port)
+		switch {
+		case owner == nil:
+			ports = append(ports, port)
+		case owner == sc && probeMsg.Adoptar:
+			ports = append(ports, port)
+			pauseOwn = true
+		default:
+			resp.Omitidos = append(resp.Omitidos, ProbeSkipped{Puerto: port, Motivo: "en_uso"})
+		}
+	}
+
+	log.Printf("[AUDIT] PROBE_STARTED | scale=%s | puertos=%s | adoptar=%v",
+		sc.ID(), strings.Join(ports, ","), probeMsg.Adoptar)
+
+	if pauseOwn {
+		log.Printf("[~] Pausando lectura de %s durante el sondeo", sc.ID())
+		sc.Operator.Pause()
+		defer sc.Operator.Resume()
+	}
+
+	probeCtx, cancel := context.WithTimeout(ctx, probeTimeout)
+	results := scale.Probe(probeCtx, ports, scale.ProbeLineSettings)
+	cancel()
+
+	for _, r := range results {
+		resp.Resultados = append(resp.Resultados, NewProbeMatch(r))
+	}
+
+	if probeMsg.Adoptar && len(results) > 0 {
+		s.adoptProbeResult(sc, results[0])
+		adopted := NewProbeMatch(results[0])
+		resp.Adoptado = &adopted
+	}
+
+	log.Printf("[AUDIT] PROBE_FINISHED | scale=%s | resultados=%d | adoptado=%v",
+		sc.ID(), len(results), resp.Adoptado != nil)
+	return resp
+}
+
+// hostPort returns the enumerated name of a serial port of the host, so
+// "com3" matches COM3
+func hostPort(ports []scale.PortInfo, name string) (string, bool) {
+	for _, p := range ports {
+		if strings.EqualFold(p.Name, name) {
+			return p.Name, true
+		}
+	}
+	return "", false
+}
+
+// adoptProbeResult applies a probe match as the scale's configuration,
+// keeping the configured read timeout.
+func (s *Server) adoptProbeResult(sc *Scale, r scale.ProbeResult) {
+	current := sc.Config.Get()
+	line := r.Serial
+	line.ReadTimeout = current.Serial.ReadTimeout
+
+	log.Printf("[AUDIT] PROBE_ADOPTED | scale=%s | puerto=%s marca=%s serial=%s",
+		sc.ID(), r.Port, r.Brand, line)
+
+	changed := sc.Config.Update(r.Port, r.Brand, false)
+	serialChanged, err := sc.Config.UpdateSerial(line)
+	if err != nil {
+		log.Printf("[X] Error applying serial settings: %v", err)
+	}
+	if (changed || serialChanged) && sc.OnConfigChange != nil {
+		sc.OnConfigChange()
+	}
+}
@@ -0,0 +1,121 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/adcondev/scale-daemon/internal/config"
+	"github.com/adcondev/scale-daemon/internal/scale"
+)
+
+// Scale bundles the components serving one scale: its configuration, the
+// broadcaster for its weight stream and the reader executing operations
+type Scale struct {
+	Config         *config.Config
+	Broadcaster    *Broadcaster
+	Operator       ScaleOperator
+	OnConfigChange func()
+}
+
+// ID returns the scale identifier used in /ws/<id>
+func (sc *Scale) ID() string {
+	return sc.Config.Get().ID
+}
+
+// unit returns the unit of the scale's readings, or "" while unknown:
+// before the first reading from an indicator that reports no unit and
+// without a configured unidad
+func (sc *Scale) unit() string {
+	if sc.Operator != nil {
+		if unit := sc.Operator.Unit(); unit != "" {
+			return unit
+		}
+	}
+	return sc.Config.Get().Unidad
+}
+
+// checkPorts checks that sc can read puerto, or its current port when
+// empty, without sharing it with another scale
+func (s *Server) checkPorts(sc *Scale, puerto string, modoPrueba bool) error {
+	snapshots := make([]config.Snapshot, 0, len(s.scales))
+	for _, other := range s.scales {
+		conf := other.Config.Get()
+		if other == sc {
+			if puerto != "" {
+				conf.Puerto = puerto
+			}
+			conf.ModoPrueba = modoPrueba
+		}
+		snapshots = append(snapshots, conf)
+	}
+	return config.CheckPorts(snapshots)
+}
+
+// status reports the scale state for /health
+func (sc *Scale) status() ScaleStatus {
+	cfg := sc.Config.Get()
+
+	status := ScaleStatus{
+		ID:         cfg.ID,
+		Port:       cfg.Puerto,
+		Brand:      cfg.Marca,
+		TestMode:   cfg.ModoPrueba,
+		Line:       cfg.Serial.String(),
+		ReadMode:   string(cfg.ModoLectura),
+		StableOnly: cfg.Estabilidad.StableOnly,
+	}
+	if sc.Operator != nil {
+		state, since := sc.Operator.State()
+		// Connected means weights are flowing, as before the state machine
+		status.Connected = state == scale.StateConnected || state == scale.StateReading
+		status.State = string(state)
+		if !since.IsZero() {
+			status.StateSince = since.Format(time.RFC3339)
+			status.StateSeconds = int(time.Since(since).Seconds())
+		}
+		if counts := sc.Operator.ErrorCounts(); len(counts) > 0 {
+			status.Errors = counts
+		}
+		if retry := sc.Operator.RetryStatus(); retry.Attempts > 0 || retry.LastError != "" {
+			status.Retry = &RetryInfo{Attempts: retry.Attempts, LastError: retry.LastError}
+			if !retry.NextAttempt.IsZero() {
+				status.Retry.NextAttempt = retry.NextAttempt.Format(time.RFC3339)
+			}
+		}
+	}
+	return status
+}
+
+// scaleFor resolves the scale a WebSocket request targets: /ws/<id>,
+// /ws?scale=<id>, or the default scale for plain /ws.
+func (s *Server) scaleFor(r *http.Request) (*Scale, bool) {
+	id := r.URL.Query().Get("scale")
+	if rest, ok := strings.CutPrefix(r.URL.Path, "/ws/"); ok && rest != "" {
+		id = rest
+	}
+	if id == "" {
+		return s.scales[0], true
+	}
+	sc := s.scaleByID(id)
+	return sc, sc != nil
+}
+
+// scaleByID returns the scale with the given ID, or nil
+func (s *Server) scaleByID(id string) *Scale {
+	for _, sc := range s.scales {
+		if sc.ID() == id {
+			return sc
+		}
+	}
+	return nil
+}
+
+// scaleIDs lists the configured scale IDs, default first
+func (s *Server) scaleIDs() []string {
+	ids := make([]string, len(s.scales))
+	for i, sc := range s.scales {
+		ids[i] = sc.ID()
+	}
+	return ids
+}
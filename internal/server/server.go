@@ -11,6 +11,9 @@ import (
 	"io/fs"
 	"log"
 	"net/http"
+	"net/url"
+	"path"
+	"strings"
 	"sync"
 	"time"
 
@@ -18,58 +21,79 @@ import (
 	"github.com/coder/websocket/wsjson"
 
 	"github.com/adcondev/scale-daemon/internal/auth"
+	"github.com/adcondev/scale-daemon/internal/calibration"
 	"github.com/adcondev/scale-daemon/internal/config"
 	"github.com/adcondev/scale-daemon/internal/logging"
+	"github.com/adcondev/scale-daemon/internal/scale"
 
 	embedded "github.com/adcondev/scale-daemon"
 )
 
 const maxConfigChangesPerMinute = 15
 
+// operationTimeout bounds how long a client waits for a tare or zero reply
+const operationTimeout = 10 * time.Second
+
+// ScaleOperator performs indicator operations on the active scale. Pause
+// and Resume release the serial port while it is being probed.
+// WaitStable waits for a settled weight to capture and WaitNewLoad for
+// the settled weight of a load placed after the previous one. RetryStatus,
+// ErrorCounts and State report the reconnect backoff, error occurrences
+// and reader state with the time it was entered for /health and for
+// estado messages on connection.
+type ScaleOperator interface {
+	Execute(ctx context.Context, op scale.Operation, params scale.OperationParams) (scale.OperationResult, error)
+	WaitStable(ctx context.Context) (scale.Reading, error)
+	WaitNewLoad(ctx context.Context, prev *scale.Reading, division float64) (scale.Reading, error)
+	Pause()
+	Resume()
+	RetryStatus() scale.RetryStatus
+	ErrorCounts() map[string]uint64
+	State() (scale.State, time.Time)
+	Unit() string
+}
+
 // Server handles HTTP and WebSocket connections
 type Server struct {
-	config         *config.Config
-	env            config.Environment
-	broadcaster    *Broadcaster
-	logMgr         *logging.Manager
-	auth           *auth.Manager
-	configLimiter  *ConfigRateLimiter
-	buildInfo      string
-	onConfigChange func()
-	buildDate      string
-	buildTime      string
-	startTime      time.Time
-	mu             sync.RWMutex
-	lastWeightTime time.Time
-	httpServer     *http.Server
-	dashboardTmpl  *template.Template
+	scales        []*Scale // scales[0] is the default scale served on /ws
+	env           config.Environment
+	logMgr        *logging.Manager
+	auth          *auth.Manager
+	calibration   *calibration.Manager // nil when the reports directory is unavailable
+	configLimiter *ConfigRateLimiter
+	probeMu       sync.Mutex // one port probe at a time
+	buildInfo     string
+	buildDate     string
+	buildTime     string
+	startTime     time.Time
+	httpServer    *http.Server
+	dashboardTmpl *template.Template
 }
 
-// NewServer creates a new server instance
+// NewServer creates a new server instance. scales must not be empty; the
+// first one is streamed to v1 clients on /ws.
 func NewServer(
-	cfg *config.Config,
+	scales []*Scale,
 	env config.Environment,
-	broadcaster *Broadcaster,
 	logMgr *logging.Manager,
 	authMgr *auth.Manager,
+	calibrationMgr *calibration.Manager,
 	buildInfo string,
-	onConfigChange func(),
 	buildDate string,
 	buildTime string,
 	startTime time.Time,
 ) *Server {
 	s := &Server{
-		config:         cfg,
-		env:            env,
-		broadcaster:    broadcaster,
-		logMgr:         logMgr,
-		auth:           authMgr,
-		configLimiter:  NewConfigRateLimiter(maxConfigChangesPerMinute), // Max 15 config changes per minute per client
-		buildInfo:      buildInfo,
-		onConfigChange: onConfigChange,
-		buildDate:      buildDate,
-		buildTime:      buildTime,
-		startTime:      startTime,
+		scales:        scales,
+		env:           env,
+		logMgr:        logMgr,
+		auth:          authMgr,
+		calibration:   calibrationMgr,
+		configLimiter: NewConfigRateLimiter(maxConfigChangesPerMinute), // Max 15 config changes per minute per client
+		buildInfo:     buildInfo,
+		buildDate:     buildDate,
+		buildTime:     buildTime,
+		startTime:     startTime,
 	}
 
 	// Setup embedded filesystem
@@ -100,10 +124,15 @@ func NewServer(
 	mux.HandleFunc("/auth/logout", s.handleLogout)
 	mux.HandleFunc("/ping", s.HandlePing)
 	mux.HandleFunc("/ws", s.handleWebSocket)
+	mux.HandleFunc("/ws/", s.handleWebSocket)
 	mux.HandleFunc("/health", s.HandleHealth)
+	mux.HandleFunc("/capture", s.HandleCapture)
 
 	// ── PROTECTED ROUTES (session required) ──────────────────
 
+	mux.HandleFunc("/ports", s.requireAuth(s.HandlePorts))
+	mux.HandleFunc("/calibration", s.requireAuth(s.HandleCalibration))
+	mux.HandleFunc("/calibration/", s.requireAuth(s.HandleCalibration))
 	mux.HandleFunc("/", s.requireAuth(s.serveDashboard))
 
 	s.httpServer = &http.Server{
@@ -226,6 +255,12 @@ func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
 // ═══════════════════════════════════════════════════════════════
 
 func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	sc, ok := s.scaleFor(r)
+	if !ok {
+		http.Error(w, "Báscula desconocida", http.StatusNotFound)
+		return
+	}
+
 	c, err := websocket.Accept(w, r, &websocket.AcceptOptions{
 		InsecureSkipVerify: true,
 		OriginPatterns:     s.allowedOrigins(),
@@ -243,14 +278,41 @@ func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 
 	ctx := r.Context()
 
-	s.broadcaster.AddClient(c)
-	log.Printf("[+] Client connected (Total: %d)", s.broadcaster.ClientCount())
+	// ?detalle=1 opts into WeightMessage objects instead of bare strings
+	// ?estado=1 opts into estado messages on reader state changes
+	opts := ClientOptions{
+		Detalle: r.URL.Query().Get("detalle") == "1",
+		Estado:  r.URL.Query().Get("estado") == "1",
+	}
+	unitErr := ""
+	if u := r.URL.Query().Get("unidad"); u != "" {
+		unit, err := scale.ParseUnit(u)
+		switch {
+		case err != nil:
+			log.Printf("[!] Unidad solicitada inválida: %v", err)
+			unitErr = "INVALID_UNIT"
+		case sc.unit() == "":
+			// Converting weights of unknown unit would mislabel them
+			unitErr = "UNIT_UNKNOWN"
+		default:
+			opts.Unidad = unit
+		}
+	}
+	sc.Broadcaster.AddClient(c, opts)
+	log.Printf("[+] Client connected to scale %s (Total: %d)", sc.ID(), sc.Broadcaster.ClientCount())
 
-	s.sendEnvironmentInfo(ctx, c)
-	s.listenForMessages(ctx, c)
+	s.sendEnvironmentInfo(ctx, c, sc)
+	if opts.Estado && sc.Operator != nil {
+		state, since := sc.Operator.State()
+		s.sendJSON(ctx, c, NewStateMessage(state, since, "", ""))
+	}
+	if unitErr != "" {
+		s.sendJSON(ctx, c, ErrorResponse{Tipo: "error", Error: unitErr})
+	}
+	s.listenForMessages(ctx, c, sc)
 
-	s.broadcaster.RemoveClient(c)
-	log.Println("[-] Client disconnected")
+	sc.Broadcaster.RemoveClient(c)
+	log.Printf("[-] Client disconnected from scale %s", sc.ID())
 }
 
 // allowedOrigins returns environment-specific WebSocket origin patterns.
@@ -262,19 +324,74 @@ func (s *Server) allowedOrigins() []string {
 	return []string{"192.168.*.*:*", "10.*.*.*:*", "172.16.*.*:*", "localhost:*"}
 }
 
-func (s *Server) sendEnvironmentInfo(ctx context.Context, c *websocket.Conn) {
-	conf := s.config.Get()
+// allowOrigin applies the WebSocket origin policy to a browser HTTP
+// request. Requests without Origin (POS software, curl) pass; a browser
+// page from another origin is rejected with 403 so it cannot read weights.
+// An allowed origin is echoed back for CORS.
+func (s *Server) allowOrigin(w http.ResponseWriter, r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	if err == nil {
+		for _, pattern := range s.allowedOrigins() {
+			if ok, _ := path.Match(pattern, strings.ToLower(u.Host)); ok {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				w.Header().Add("Vary", "Origin")
+				return true
+			}
+		}
+	}
+	log.Printf("[AUDIT] ORIGIN_REJECTED | path=%s | origin=%s | client=%s", r.URL.Path, origin, r.RemoteAddr)
+	http.Error(w, "Origin not allowed", http.StatusForbidden)
+	return false
+}
+
+func (s *Server) sendEnvironmentInfo(ctx context.Context, c *websocket.Conn, sc *Scale) {
+	conf := sc.Config.Get()
 
 	envInfo := EnvironmentInfo{
 		Tipo:     "ambiente",
 		Ambiente: conf.Ambiente,
 		Version:  s.buildInfo,
+		Basculas: s.scaleIDs(),
 		Config: ConfigForClient{
+			ID:         conf.ID,
 			Puerto:     conf.Puerto,
 			Marca:      conf.Marca,
 			ModoPrueba: conf.ModoPrueba,
 			Dir:        conf.Dir,
 			Ambiente:   conf.Ambiente,
+
+			Baudios:          conf.Serial.BaudRate,
+			BitsDatos:        conf.Serial.DataBits,
+			Paridad:          conf.Serial.Parity,
+			BitsParada:       conf.Serial.StopBits,
+			TimeoutLecturaMs: int(conf.Serial.ReadTimeout / time.Millisecond),
+			ModoLectura:      string(conf.ModoLectura),
+
+			VentanaEstabilidad:    conf.Estabilidad.Window,
+			ToleranciaEstabilidad: conf.Estabilidad.Tolerance,
+			SoloEstable:           conf.Estabilidad.StableOnly,
+			Division:              conf.Division,
+			Unidad:                conf.Unidad,
+			Escenario:             conf.Escenario,
+			Captura:               conf.Captura,
+
+			ReintentoInicialMs: int(conf.Reintento.Initial / time.Millisecond),
+			ReintentoMaxMs:     int(conf.Reintento.Max / time.Millisecond),
+			ReintentoFactor:    conf.Reintento.Multiplier,
+			ReintentoJitter:    conf.Reintento.Jitter,
+
+			BandaMuerta: conf.Publicacion.Deadband,
+			LatidoMs:    int(conf.Publicacion.Heartbeat / time.Millisecond),
+
+			Capacidad: conf.Rango.Capacity,
+			Minimo:    conf.Rango.Minimum,
 		},
 	}
 
@@ -283,7 +400,7 @@ func (s *Server) sendEnvironmentInfo(ctx context.Context, c *websocket.Conn) {
 	_ = wsjson.Write(ctx2, c, envInfo)
 }
 
-func (s *Server) listenForMessages(ctx context.Context, c *websocket.Conn) {
+func (s *Server) listenForMessages(ctx context.Context, c *websocket.Conn, sc *Scale) {
 	log.Println("[i] Iniciando escucha de mensajes del cliente...")
 	defer log.Println("[i] Terminando escucha de mensajes del cliente.")
 
@@ -308,11 +425,11 @@ func (s *Server) listenForMessages(ctx context.Context, c *websocket.Conn) {
 			continue
 		}
 
-		s.handleMessage(ctx, c, tipo, mensaje)
+		s.handleMessage(ctx, c, sc, tipo, mensaje)
 	}
 }
 
-func (s *Server) handleMessage(ctx context.Context, c *websocket.Conn, tipo string, mensaje map[string]interface{}) {
+func (s *Server) handleMessage(ctx context.Context, c *websocket.Conn, sc *Scale, tipo string, mensaje map[string]interface{}) {
 	switch tipo {
 	case "config":
 		// ── RATE LIMIT CHECK ─────────────────────────────────
@@ -323,7 +440,61 @@ func (s *Server) handleMessage(ctx context.Context, c *websocket.Conn, tipo stri
 			s.sendJSON(ctx, c, ErrorResponse{Tipo: "error", Error: "RATE_LIMITED"})
 			return
 		}
-		s.handleConfigMessage(ctx, c, mensaje)
+		s.handleConfigMessage(ctx, c, sc, mensaje)
+
+	case "tare", "zero", "clearTare", "presetTare":
+		// ── RATE LIMIT CHECK ─────────────────────────────────
+		// Operations share the config limiter so a client cannot flood the indicator
+		clientAddr := fmt.Sprintf("%p", c)
+		if !s.configLimiter.Allow(clientAddr) {
+			log.Printf("[AUDIT] OPERATION_RATE_LIMITED | client=%s | tipo=%s", clientAddr, tipo)
+			s.sendJSON(ctx, c, ErrorResponse{Tipo: "error", Error: "RATE_LIMITED"})
+			return
+		}
+		s.handleOperationMessage(ctx, c, sc, mensaje)
+
+	case "capture":
+		// ── RATE LIMIT CHECK ─────────────────────────────────
+		clientAddr := fmt.Sprintf("%p", c)
+		if !s.configLimiter.Allow(clientAddr) {
+			log.Printf("[AUDIT] CAPTURE_RATE_LIMITED | client=%s", clientAddr)
+			s.sendJSON(ctx, c, ErrorResponse{Tipo: "error", Error: "RATE_LIMITED"})
+			return
+		}
+		s.handleCaptureMessage(ctx, c, sc, mensaje)
+
+	case "listPorts":
+		s.handleListPortsMessage(ctx, c)
+
+	case "probe":
+		// ── RATE LIMIT CHECK ─────────────────────────────────
+		clientAddr := fmt.Sprintf("%p", c)
+		if !s.configLimiter.Allow(clientAddr) {
+			log.Printf("[AUDIT] PROBE_RATE_LIMITED | client=%s", clientAddr)
+			s.sendJSON(ctx, c, ErrorResponse{Tipo: "error", Error: "RATE_LIMITED"})
+			return
+		}
+		s.handleProbeMessage(ctx, c, sc, mensaje)
+
+	case "units":
+		unidad, _ := mensaje["unidad"].(string)
+		unit := ""
+		if unidad != "" {
+			var err error
+			if unit, err = scale.ParseUnit(unidad); err != nil {
+				log.Printf("[!] Unidad solicitada inválida: %v", err)
+				s.sendJSON(ctx, c, ErrorResponse{Tipo: "error", Error: "INVALID_UNIT"})
+				return
+			}
+			if sc.unit() == "" {
+				// Converting weights of unknown unit would mislabel them
+				log.Printf("[!] Unidad %s rechazada: la báscula %s no informa su unidad", unit, sc.ID())
+				s.sendJSON(ctx, c, ErrorResponse{Tipo: "error", Error: "UNIT_UNKNOWN"})
+				return
+			}
+		}
+		sc.Broadcaster.SetClientUnit(c, unit)
+		s.sendJSON(ctx, c, UnitsMessage{Tipo: "units", Unidad: unit})
 
 	case "logConfig":
 		if v, ok := mensaje["verbose"].(bool); ok {
@@ -358,7 +529,7 @@ func (s *Server) handleMessage(ctx context.Context, c *websocket.Conn, tipo stri
 	}
 }
 
-func (s *Server) handleConfigMessage(ctx context.Context, c *websocket.Conn, mensaje map[string]interface{}) {
+func (s *Server) handleConfigMessage(ctx context.Context, c *websocket.Conn, sc *Scale, mensaje map[string]interface{}) {
 	// Parse into struct for type safety
 	data, _ := json.Marshal(mensaje)
 	var configMsg ConfigMessage
@@ -375,20 +546,231 @@ func (s *Server) handleConfigMessage(ctx context.Context, c *websocket.Conn, men
 		return
 	}
 
-	log.Printf("[AUDIT] CONFIG_ACCEPTED | puerto=%s marca=%s modoPrueba=%v",
-		configMsg.Puerto, configMsg.Marca, configMsg.ModoPrueba)
+	// ── BRAND VALIDATION ─────────────────────────────────────
+	if configMsg.Marca != "" {
+		if _, err := scale.LookupDriver(configMsg.Marca); err != nil {
+			log.Printf("[AUDIT] CONFIG_REJECTED | reason=unknown_brand | marca=%s", configMsg.Marca)
+			s.sendJSON(ctx, c, ErrorResponse{Tipo: "error", Error: "UNKNOWN_BRAND"})
+			return
+		}
+	}
+
+	// ── PORT VALIDATION ──────────────────────────────────────
+	if err := s.checkPorts(sc, configMsg.Puerto, configMsg.ModoPrueba); err != nil {
+		log.Printf("[AUDIT] CONFIG_REJECTED | reason=port_in_use | %v", err)
+		s.sendJSON(ctx, c, ErrorResponse{Tipo: "error", Error: "PORT_IN_USE"})
+		return
+	}
+
+	// ── SERIAL LINE VALIDATION ───────────────────────────────
+	// Validate the merged settings before anything is applied so an invalid
+	// line configuration never reopens the port.
+	serialSettings := configMsg.SerialSettings().Merge(sc.Config.Get().Serial)
+	if err := serialSettings.Validate(); err != nil {
+		log.Printf("[AUDIT] CONFIG_REJECTED | reason=invalid_serial | %v", err)
+		s.sendJSON(ctx, c, ErrorResponse{Tipo: "error", Error: "INVALID_SERIAL"})
+		return
+	}
 
-	if s.config.Update(configMsg.Puerto, configMsg.Marca, configMsg.ModoPrueba) {
+	// ── READ MODE VALIDATION ─────────────────────────────────
+	readMode := sc.Config.Get().ModoLectura
+	if configMsg.ModoLectura != "" {
+		mode, err := config.ParseReadMode(configMsg.ModoLectura)
+		if err != nil {
+			log.Printf("[AUDIT] CONFIG_REJECTED | reason=invalid_read_mode | %v", err)
+			s.sendJSON(ctx, c, ErrorResponse{Tipo: "error", Error: "INVALID_READ_MODE"})
+			return
+		}
+		readMode = mode
+	}
+
+	// ── STABILITY VALIDATION ─────────────────────────────────
+	current := sc.Config.Get()
+	stability, division := configMsg.StabilitySettings(current.Estabilidad, current.Division)
+	if err := stability.Validate(); err != nil || division < 0 {
+		log.Printf("[AUDIT] CONFIG_REJECTED | reason=invalid_stability | %+v division=%v", stability, division)
+		s.sendJSON(ctx, c, ErrorResponse{Tipo: "error", Error: "INVALID_STABILITY"})
+		return
+	}
+
+	// ── UNIT VALIDATION ──────────────────────────────────────
+	unidad := current.Unidad
+	if configMsg.Unidad != nil {
+		unidad = *configMsg.Unidad
+		if unidad != "" {
+			unit, err := scale.ParseUnit(unidad)
+			if err != nil {
+				log.Printf("[AUDIT] CONFIG_REJECTED | reason=invalid_unit | %v", err)
+				s.sendJSON(ctx, c, ErrorResponse{Tipo: "error", Error: "INVALID_UNIT"})
+				return
+			}
+			unidad = unit
+		}
+	}
+
+	// ── SCENARIO VALIDATION ──────────────────────────────────
+	escenario := current.Escenario
+	if configMsg.Escenario != nil {
+		escenario = *configMsg.Escenario
+		if escenario != "" {
+			if _, err := scale.LoadNamedScenario(escenario); err != nil {
+				log.Printf("[AUDIT] CONFIG_REJECTED | reason=invalid_scenario | %v", err)
+				s.sendJSON(ctx, c, ErrorResponse{Tipo: "error", Error: "INVALID_SCENARIO"})
+				return
+			}
+		}
+	}
+
+	// ── CAPTURE VALIDATION ───────────────────────────────────
+	captura := current.Captura
+	if configMsg.Captura != nil {
+		captura = *configMsg.Captura
+		if captura != "" && captura != current.Captura {
+			if err := scale.CheckCapture(captura); err != nil {
+				log.Printf("[AUDIT] CONFIG_REJECTED | reason=invalid_capture | %v", err)
+				s.sendJSON(ctx, c, ErrorResponse{Tipo: "error", Error: "INVALID_CAPTURE"})
+				return
+			}
+		}
+	}
+
+	// ── RETRY VALIDATION ─────────────────────────────────────
+	retry := configMsg.RetrySettings(current.Reintento)
+	if err := retry.Validate(); err != nil {
+		log.Printf("[AUDIT] CONFIG_REJECTED | reason=invalid_retry | %v", err)
+		s.sendJSON(ctx, c, ErrorResponse{Tipo: "error", Error: "INVALID_RETRY"})
+		return
+	}
+
+	// ── PUBLISH VALIDATION ───────────────────────────────────
+	publish := configMsg.PublishSettings(current.Publicacion)
+	if err := publish.Validate(); err != nil {
+		log.Printf("[AUDIT] CONFIG_REJECTED | reason=invalid_publish | %v", err)
+		s.sendJSON(ctx, c, ErrorResponse{Tipo: "error", Error: "INVALID_PUBLISH"})
+		return
+	}
+
+	// ── RANGE VALIDATION ─────────────────────────────────────
+	rng := configMsg.RangeSettings(current.Rango)
+	if err := rng.Validate(); err != nil {
+		log.Printf("[AUDIT] CONFIG_REJECTED | reason=invalid_range | %v", err)
+		s.sendJSON(ctx, c, ErrorResponse{Tipo: "error", Error: "INVALID_RANGE"})
+		return
+	}
+
+	log.Printf("[AUDIT] CONFIG_ACCEPTED | puerto=%s marca=%s modoPrueba=%v serial=%s modoLectura=%s soloEstable=%v",
+		configMsg.Puerto, configMsg.Marca, configMsg.ModoPrueba, serialSettings, readMode, stability.StableOnly)
+
+	changed := sc.Config.Update(configMsg.Puerto, configMsg.Marca, configMsg.ModoPrueba)
+	serialChanged, err := sc.Config.UpdateSerial(serialSettings)
+	if err != nil {
+		log.Printf("[X] Error applying serial settings: %v", err)
+	}
+	readModeChanged := sc.Config.UpdateReadMode(readMode)
+	stabilityChanged, err := sc.Config.UpdateStability(stability, division)
+	if err != nil {
+		log.Printf("[X] Error applying stability settings: %v", err)
+	}
+
+	unitChanged := sc.Config.UpdateUnit(unidad)
+	scenarioChanged := sc.Config.UpdateScenario(escenario)
+	// The reader applies the capture on reconnect, so it starts with the
+	// port being opened
+	captureChanged := sc.Config.UpdateCapture(captura)
+	if captureChanged {
+		log.Printf("[AUDIT] CAPTURE_CHANGED | scale=%s | captura=%q", current.ID, captura)
+	}
+	retryChanged, err := sc.Config.UpdateRetry(retry)
+	if err != nil {
+		log.Printf("[X] Error applying retry settings: %v", err)
+	}
+	publishChanged, err := sc.Config.UpdatePublish(publish)
+	if err != nil {
+		log.Printf("[X] Error applying publish settings: %v", err)
+	}
+	rangeChanged, err := sc.Config.UpdateRange(rng)
+	if err != nil {
+		log.Printf("[X] Error applying range settings: %v", err)
+	}
+
+	// The backoff applies from the next attempt, so it needs no reconnect
+	switch {
+	case changed || serialChanged || readModeChanged || stabilityChanged || scenarioChanged || captureChanged || publishChanged || rangeChanged:
 		log.Println("[*] Cambiando configuración...")
-		if s.onConfigChange != nil {
-			s.onConfigChange()
+		if sc.OnConfigChange != nil {
+			sc.OnConfigChange()
 		}
 		log.Println("[OK] Configuración actualizada")
-	} else {
+	case retryChanged:
+		log.Printf("[OK] Reintentos actualizados: %s a %s, factor %v, jitter %v",
+			retry.Initial, retry.Max, retry.Multiplier, retry.Jitter)
+	case unitChanged:
+		// Applies from the next reading
+		log.Printf("[OK] Unidad de lecturas sin unidad: %q", unidad)
+	default:
 		log.Println("[i] Configuración sin cambios")
 	}
 }
 
+func (s *Server) handleOperationMessage(ctx context.Context, c *websocket.Conn, sc *Scale, mensaje map[string]interface{}) {
+	data, _ := json.Marshal(mensaje)
+	var opMsg OperationMessage
+	if err := json.Unmarshal(data, &opMsg); err != nil {
+		log.Printf("[X] Error parsing operation message: %v", err)
+		return
+	}
+
+	// ── TOKEN VALIDATION ─────────────────────────────────────
+	if config.AuthToken != "" && opMsg.AuthToken != config.AuthToken {
+		log.Printf("[AUDIT] OPERATION_REJECTED | reason=invalid_token | tipo=%s", opMsg.Tipo)
+		s.sendJSON(ctx, c, ErrorResponse{Tipo: "error", Error: "AUTH_INVALID_TOKEN"})
+		return
+	}
+
+	op := scale.Operation(opMsg.Tipo)
+	resp := OperationResponse{Tipo: "resultado", Operacion: opMsg.Tipo}
+
+	opCtx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+	res, err := sc.Operator.Execute(opCtx, op, scale.OperationParams{Value: opMsg.Valor, Unit: opMsg.Unidad})
+	if err != nil {
+		resp.Error = operationErrorCode(err)
+		log.Printf("[AUDIT] OPERATION_FAILED | tipo=%s | error=%s | %v", op, resp.Error, err)
+		s.sendJSON(ctx, c, resp)
+		return
+	}
+
+	resp.OK = true
+	resp.Valor = res.Value
+	resp.Unidad = res.Unit
+	resp.Origen = "indicador"
+	if res.Software {
+		resp.Origen = "software"
+	}
+	log.Printf("[AUDIT] OPERATION_ACCEPTED | tipo=%s | valor=%v %s | origen=%s", op, res.Value, res.Unit, resp.Origen)
+	s.sendJSON(ctx, c, resp)
+}
+
+// operationErrorCode maps an operation failure to the code sent to clients
+func operationErrorCode(err error) string {
+	var statusErr *scale.StatusError
+	switch {
+	case errors.Is(err, scale.ErrUnsupportedOperation):
+		return "UNSUPPORTED_OPERATION"
+	case errors.Is(err, scale.ErrNoReading):
+		return "NO_READING"
+	case errors.Is(err, scale.ErrInMotion):
+		return "WEIGHT_IN_MOTION"
+	case errors.Is(err, scale.ErrInvalidTare):
+		return "INVALID_TARE"
+	case errors.Is(err, context.DeadlineExceeded):
+		return scale.ErrTimeout
+	case errors.As(err, &statusErr):
+		return statusErr.Code
+	}
+	return "OPERATION_FAILED"
+}
+
 // ═══════════════════════════════════════════════════════════════
 // HTTP ENDPOINTS
 // ═══════════════════════════════════════════════════════════════
@@ -401,26 +783,18 @@ func (s *Server) HandlePing(w http.ResponseWriter, _ *http.Request) {
 	_, _ = w.Write([]byte("pong"))
 }
 
-// HandleHealth returns service health and scale connection status.
+// HandleHealth returns service health and the connection status of each
+// scale. "scale" keeps reporting the default scale for v1 monitors.
 func (s *Server) HandleHealth(w http.ResponseWriter, _ *http.Request) {
-	cfg := s.config.Get()
-
-	s.mu.RLock()
-	isConnected := !s.lastWeightTime.IsZero() && time.Since(s.lastWeightTime) < 15*time.Second
-	s.mu.RUnlock()
-
-	if cfg.ModoPrueba {
-		isConnected = true
+	statuses := make([]ScaleStatus, len(s.scales))
+	for i, sc := range s.scales {
+		statuses[i] = sc.status()
 	}
 
 	response := HealthResponse{
 		Status: "ok",
-		Scale: ScaleStatus{
-			Connected: isConnected,
-			Port:      cfg.Puerto,
-			Brand:     cfg.Marca,
-			TestMode:  cfg.ModoPrueba,
-		},
+		Scale:  statuses[0],
+		Scales: statuses,
 		Build: BuildInfo{
 			Env:  s.env.Name,
 			Date: s.buildDate,
@@ -440,17 +814,10 @@ func (s *Server) sendJSON(ctx context.Context, c *websocket.Conn, v interface{})
 	_ = wsjson.Write(ctx2, c, v)
 }
 
-// RecordWeightActivity updates the last weight timestamp for health checks.
-func (s *Server) RecordWeightActivity() {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.lastWeightTime = time.Now()
-}
-
 // ListenAndServe starts the HTTP server and logs the active endpoints and auth status.
 func (s *Server) ListenAndServe() error {
 	log.Printf("[i] Dashboard active at http://%s/", s.env.ListenAddr)
-	log.Printf("[i] WebSocket active at ws://%s/ws", s.env.ListenAddr)
+	log.Printf("[i] WebSocket active at ws://%s/ws (scales: %s)", s.env.ListenAddr, strings.Join(s.scaleIDs(), ", "))
 	log.Printf("[i] Auth enabled: %v", s.auth.Enabled())
 	return s.httpServer.ListenAndServe()
 }
@@ -0,0 +1,220 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+
+	"github.com/adcondev/scale-daemon/internal/auth"
+	"github.com/adcondev/scale-daemon/internal/calibration"
+	"github.com/adcondev/scale-daemon/internal/config"
+	"github.com/adcondev/scale-daemon/internal/scale"
+)
+
+// fakeOperator stands in for a scale.Reader. WaitStable returns reading
+// right away, or waits for ctx to end and returns err when it is set.
+// WaitNewLoad does the same unless reading is the previous load.
+type fakeOperator struct {
+	mu      sync.Mutex
+	reading scale.Reading
+	err     error
+	unit    string
+}
+
+func (f *fakeOperator) Execute(context.Context, scale.Operation, scale.OperationParams) (scale.OperationResult, error) {
+	return scale.OperationResult{}, scale.ErrUnsupportedOperation
+}
+
+func (f *fakeOperator) WaitStable(ctx context.Context) (scale.Reading, error) {
+	f.mu.Lock()
+	reading, err := f.reading, f.err
+	f.mu.Unlock()
+	if err == nil {
+		return reading, nil
+	}
+	<-ctx.Done()
+	return scale.Reading{}, err
+}
+
+func (f *fakeOperator) WaitNewLoad(ctx context.Context, prev *scale.Reading, _ float64) (scale.Reading, error) {
+	f.mu.Lock()
+	same := f.err == nil && prev != nil && f.reading.Value == prev.Value
+	f.mu.Unlock()
+	if same {
+		<-ctx.Done()
+		return scale.Reading{}, scale.ErrNoNewLoad
+	}
+	return f.WaitStable(ctx)
+}
+
+func (f *fakeOperator) set(reading scale.Reading, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.reading, f.err = reading, err
+}
+
+func (f *fakeOperator) Pause()                          {}
+func (f *fakeOperator) Resume()                         {}
+func (f *fakeOperator) RetryStatus() scale.RetryStatus  { return scale.RetryStatus{} }
+func (f *fakeOperator) ErrorCounts() map[string]uint64  { return nil }
+func (f *fakeOperator) State() (scale.State, time.Time) { return scale.StateReading, time.Now() }
+
+func (f *fakeOperator) Unit() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.unit
+}
+
+// testScale builds a scale on puerto served by a fake reader
+func testScale(t *testing.T, id, puerto string) (*Scale, *fakeOperator) {
+	t.Helper()
+	cfg, err := config.NewScale(config.Environment{Name: "LOCAL"}, config.ScaleDefinition{ID: id, Puerto: puerto})
+	if err != nil {
+		t.Fatal(err)
+	}
+	op := &fakeOperator{}
+	return &Scale{Config: cfg, Broadcaster: NewBroadcaster(make(chan scale.Event), nil), Operator: op}, op
+}
+
+// newTestServer serves scales over HTTP; the first one is the default
+func newTestServer(t *testing.T, calibrationMgr *calibration.Manager, scales ...*Scale) (*Server, *httptest.Server) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	env := config.Environment{Name: "LOCAL", ListenAddr: "localhost:0"}
+	s := NewServer(scales, env, nil, auth.NewManager(ctx), calibrationMgr, "test", "", "", time.Now())
+	ts := httptest.NewServer(s.httpServer.Handler)
+	t.Cleanup(ts.Close)
+	return s, ts
+}
+
+// dial connects a WebSocket client to path and returns the ambiente message
+func dial(t *testing.T, ts *httptest.Server, path string) (*websocket.Conn, map[string]interface{}) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	c, _, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(ts.URL, "http")+path, nil)
+	if err != nil {
+		t.Fatalf("Dial(%s) error: %v", path, err)
+	}
+	t.Cleanup(func() { _ = c.CloseNow() })
+	return c, readMessage(t, c)
+}
+
+func readMessage(t *testing.T, c *websocket.Conn) map[string]interface{} {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	var msg map[string]interface{}
+	if err := wsjson.Read(ctx, c, &msg); err != nil {
+		t.Fatalf("Read() error: %v", err)
+	}
+	return msg
+}
+
+func sendMessage(t *testing.T, c *websocket.Conn, msg map[string]interface{}) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := wsjson.Write(ctx, c, msg); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+}
+
+func TestWebSocketRouting(t *testing.T) {
+	mostrador, _ := testScale(t, "mostrador", "COM3")
+	piso, _ := testScale(t, "piso", "COM4")
+	_, ts := newTestServer(t, nil, mostrador, piso)
+
+	for path, want := range map[string]string{
+		"/ws":            "mostrador",
+		"/ws/piso":       "piso",
+		"/ws?scale=piso": "piso",
+		"/ws/mostrador":  "mostrador",
+	} {
+		_, env := dial(t, ts, path)
+		conf, _ := env["config"].(map[string]interface{})
+		if env["tipo"] != "ambiente" || conf["id"] != want {
+			t.Errorf("Dial(%s) ambiente = %v, want scale %s", path, env, want)
+		}
+		if basculas, _ := env["basculas"].([]interface{}); len(basculas) != 2 || basculas[0] != "mostrador" {
+			t.Errorf("Dial(%s) basculas = %v", path, env["basculas"])
+		}
+	}
+
+	for _, path := range []string{"/ws/nada", "/ws?scale=nada"} {
+		resp, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("GET %s = %d, want 404", path, resp.StatusCode)
+		}
+	}
+}
+
+func TestConfigMessageValidation(t *testing.T) {
+	origDir := scale.CaptureDir
+	scale.CaptureDir = t.TempDir()
+	defer func() { scale.CaptureDir = origDir }()
+
+	mostrador, _ := testScale(t, "mostrador", "COM3")
+	piso, _ := testScale(t, "piso", "COM4")
+	var reconnects atomic.Int32
+	mostrador.OnConfigChange = func() { reconnects.Add(1) }
+	_, ts := newTestServer(t, nil, mostrador, piso)
+	c, _ := dial(t, ts, "/ws/mostrador")
+
+	config.AuthToken = "secreto"
+	sendMessage(t, c, map[string]interface{}{"tipo": "config", "puerto": "COM5"})
+	if msg := readMessage(t, c); msg["error"] != "AUTH_INVALID_TOKEN" {
+		t.Errorf("Config without token = %v, want AUTH_INVALID_TOKEN", msg)
+	}
+	config.AuthToken = ""
+
+	for _, tt := range []struct {
+		msg  map[string]interface{}
+		want string
+	}{
+		{map[string]interface{}{"puerto": "com4"}, "PORT_IN_USE"},
+		{map[string]interface{}{"marca": "Balanza X"}, "UNKNOWN_BRAND"},
+		{map[string]interface{}{"baudios": 1234}, "INVALID_SERIAL"},
+		{map[string]interface{}{"modoLectura": "push"}, "INVALID_READ_MODE"},
+		{map[string]interface{}{"division": -1}, "INVALID_STABILITY"},
+		{map[string]interface{}{"unidad": "st"}, "INVALID_UNIT"},
+		{map[string]interface{}{"escenario": "../x.json"}, "INVALID_SCENARIO"},
+		{map[string]interface{}{"captura": `C:\x.cap`}, "INVALID_CAPTURE"},
+		{map[string]interface{}{"capacidad": -30}, "INVALID_RANGE"},
+	} {
+		tt.msg["tipo"] = "config"
+		sendMessage(t, c, tt.msg)
+		if msg := readMessage(t, c); msg["tipo"] != "error" || msg["error"] != tt.want {
+			t.Errorf("Config %v = %v, want %s", tt.msg, msg, tt.want)
+		}
+	}
+	if got := mostrador.Config.Get(); got.Puerto != "COM3" || got.Unidad != "" || reconnects.Load() != 0 {
+		t.Fatalf("Rejected messages changed the config: %+v, %d reconnects", got, reconnects.Load())
+	}
+
+	// A valid change is applied without a reply and reopens the port
+	sendMessage(t, c, map[string]interface{}{"tipo": "config", "puerto": "COM5", "unidad": "KG"})
+	deadline := time.Now().Add(2 * time.Second)
+	for reconnects.Load() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the config change")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := mostrador.Config.Get(); got.Puerto != "COM5" || got.Unidad != "kg" {
+		t.Errorf("Applied config = %s, unidad %q", got.Puerto, got.Unidad)
+	}
+}